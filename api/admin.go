@@ -6,6 +6,16 @@ import (
 	"net/http"
 )
 
+// HandleGenerateOtp issues a one-time pairing code for a new connecting
+// peer. The code itself never crosses the /connect websocket - it seeds
+// both sides' PAKE exchange in websocket.ServeWs/DialClientPake (see
+// pkg/pake) - so this endpoint, not the wire, is the only place it's ever
+// exposed in plaintext.
+//
+// TODO: in the mesh topology this becomes the "device pairing" step -
+// consuming the code should add the pairing peer's identity.DeviceID to
+// the folder's trust list, and a check of the connecting peer's
+// identity.DeviceID should replace it as the per-connect handshake check.
 func HandleGenerateOtp(w http.ResponseWriter, r *http.Request) {
 	utils.LogInfo("New one time password requested by admin")
 	token := r.URL.Query().Get("t")
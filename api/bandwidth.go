@@ -0,0 +1,34 @@
+package api
+
+import (
+	"JustSync/websocket"
+	"fmt"
+	"net/http"
+)
+
+// HandleBandwidthMetrics reports every connected peer's raw bandwidth
+// account - bytes in/out and message counts by payload type - in
+// Prometheus text exposition format, so an operator can spot a runaway
+// client the same way they'd spot any other counter climbing too fast.
+func HandleBandwidthMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshots := websocket.Bandwidth.Snapshot()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# TYPE justsync_peer_bytes_in_total counter\n")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "justsync_peer_bytes_in_total{peer=%q} %d\n", s.PeerID, s.BytesIn)
+	}
+	fmt.Fprintf(w, "# TYPE justsync_peer_bytes_out_total counter\n")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "justsync_peer_bytes_out_total{peer=%q} %d\n", s.PeerID, s.BytesOut)
+	}
+	fmt.Fprintf(w, "# TYPE justsync_peer_messages_total counter\n")
+	for _, s := range snapshots {
+		for payloadType, n := range s.CountsIn {
+			fmt.Fprintf(w, "justsync_peer_messages_total{peer=%q,type=%q,direction=\"in\"} %d\n", s.PeerID, payloadType, n)
+		}
+		for payloadType, n := range s.CountsOut {
+			fmt.Fprintf(w, "justsync_peer_messages_total{peer=%q,type=%q,direction=\"out\"} %d\n", s.PeerID, payloadType, n)
+		}
+	}
+}
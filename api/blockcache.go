@@ -0,0 +1,23 @@
+package api
+
+import (
+	"JustSync/service"
+	"fmt"
+	"net/http"
+)
+
+// HandleBlockCacheMetrics reports the shared chunk block cache's
+// hit/miss/eviction counters in Prometheus text exposition format, so they
+// can be scraped the same way as any other gauge/counter without pulling
+// in a client library this codebase doesn't otherwise depend on.
+func HandleBlockCacheMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := service.ChunkCacheStats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# TYPE justsync_blockcache_hits_total counter\n")
+	fmt.Fprintf(w, "justsync_blockcache_hits_total %d\n", stats.Hits)
+	fmt.Fprintf(w, "# TYPE justsync_blockcache_misses_total counter\n")
+	fmt.Fprintf(w, "justsync_blockcache_misses_total %d\n", stats.Misses)
+	fmt.Fprintf(w, "# TYPE justsync_blockcache_evictions_total counter\n")
+	fmt.Fprintf(w, "justsync_blockcache_evictions_total %d\n", stats.Evictions)
+}
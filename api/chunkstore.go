@@ -0,0 +1,61 @@
+package api
+
+import (
+	"JustSync/service/chunkstore"
+	"JustSync/utils"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// HandlePrune runs chunkstore.Prune, removing chunks that have sat
+// unreferenced for at least the "minAge" query parameter (a Go duration
+// string, e.g. "24h"; defaults to 0 - prune anything currently
+// unreferenced).
+func HandlePrune(w http.ResponseWriter, r *http.Request) {
+	minAge, err := parseMinAge(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	utils.LogInfo("Prune requested (minAge=%s)", minAge)
+	removed, err := chunkstore.Prune(minAge)
+	if err != nil {
+		utils.LogError("Prune failed: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writePruneReport(w, removed)
+}
+
+// HandleGC runs chunkstore.GC, rebuilding the refcount ledger from the
+// current project snapshot before pruning every blob it no longer
+// references.
+func HandleGC(w http.ResponseWriter, r *http.Request) {
+	utils.LogInfo("GC requested")
+	removed, err := chunkstore.GC()
+	if err != nil {
+		utils.LogError("GC failed: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writePruneReport(w, removed)
+}
+
+func parseMinAge(r *http.Request) (time.Duration, error) {
+	raw := r.URL.Query().Get("minAge")
+	if raw == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(raw)
+}
+
+func writePruneReport(w http.ResponseWriter, removed int) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Removed int `json:"removed"`
+	}{Removed: removed})
+}
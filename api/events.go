@@ -0,0 +1,41 @@
+package api
+
+import (
+	"JustSync/service"
+	"JustSync/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HandleEvents streams every service.Events sync lifecycle event to the
+// client as Server-Sent Events, so a desktop client can render live sync
+// status without tailing logs. The connection stays open until the client
+// disconnects or the server shuts down.
+func HandleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events := service.Events.SubscribeAll()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				utils.LogError("Could not marshal event for /events stream: %s", err.Error())
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
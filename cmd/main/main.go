@@ -2,20 +2,34 @@ package main
 
 import (
 	"JustSync/api"
+	"JustSync/filter"
+	"JustSync/mount"
+	"JustSync/service"
+	"JustSync/service/chunkstore"
+	"JustSync/service/watcher"
+	"JustSync/snapshot"
 	"JustSync/utils"
+	"JustSync/utils/compress"
+	socket "JustSync/websocket"
 	"bufio"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	serverCfgFile string
-	peerCfgFile   string
+	serverCfgFile   string
+	peerCfgFile     string
+	resumeSessionID string
+	relayCode       string
+	relayListenPort string
+	mountPoint      string
 
 	rootCmd = &cobra.Command{
 		Use:   "justsync",
@@ -40,7 +54,7 @@ var (
 		Short: "Run the peer as host mode",
 		Long:  "The longer version, TODO",
 		Run: func(cmd *cobra.Command, args []string) {
-			// TODO:
+			runHost(peerCfgFile)
 		},
 	}
 	joinCmd = &cobra.Command{
@@ -48,7 +62,49 @@ var (
 		Short: "Joins a running session as plain peer",
 		Long:  "The longer version, TODO",
 		Run: func(cmd *cobra.Command, args []string) {
-			// TODO:
+			runJoin(peerCfgFile, resumeSessionID)
+		},
+	}
+	pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Deletes chunk store blobs no longer referenced by the project snapshot",
+		Long: "Rebuilds the chunk store's refcount ledger from the on-disk " +
+			"project snapshot and deletes every blob it no longer references. Run " +
+			"this periodically on a long-lived host to reclaim disk space from " +
+			"content that's since been edited or deleted - chunk content is kept " +
+			"around indefinitely otherwise, since any snapshot entry might still " +
+			"point at it.",
+		Run: func(cmd *cobra.Command, args []string) {
+			runPrune()
+		},
+	}
+	relayCmd = &cobra.Command{
+		Use:   "relay",
+		Short: "Runs a public rendezvous relay for NAT-traversed pairing",
+		Long: "Runs a standalone public rendezvous relay: it never touches a " +
+			"project snapshot or chunk store, only pipes bytes between a host " +
+			"registered under a pairing code and the client that later dials in " +
+			"with it. Run this somewhere reachable from both a host and its " +
+			"peers - a small cloud VM is enough - so neither side needs to be " +
+			"reachable from the other directly; `justsync peer host --relay-code` " +
+			"and `justsync peer join --relay-code` are the two sides that " +
+			"actually pair through it.",
+		Run: func(cmd *cobra.Command, args []string) {
+			runRelay(relayListenPort)
+		},
+	}
+	mountCmd = &cobra.Command{
+		Use:   "mount",
+		Short: "Mounts the on-disk project snapshot as a read-only FUSE filesystem",
+		Long: "Loads the on-disk project snapshot and serves it read-only at --at " +
+			"via FUSE, so it can be ls'd/grep'd directly without materializing " +
+			"every file to disk first - directory structure and file sizes come " +
+			"straight from the snapshot, and a read pulls only the chunks it " +
+			"actually needs out of the shared chunk store. Blocks until the " +
+			"filesystem is unmounted (e.g. `umount`/`fusermount -u`) or FUSE " +
+			"errors out.",
+		Run: func(cmd *cobra.Command, args []string) {
+			runMount(mountPoint)
 		},
 	}
 )
@@ -74,11 +130,26 @@ func init() {
 	peerCmd.AddCommand(hostCmd)
 	hostCmd.PersistentFlags().StringVar(&peerCfgFile, "config", "", "config file (required)")
 	hostCmd.MarkPersistentFlagRequired("config")
+	hostCmd.Flags().StringVar(&relayCode, "relay-code", "", "pairing code from the host's `justsync server`, to rendezvous via session.host.relayUrl instead of dialing session.host.url directly")
 
 	// Register peer join sub-command
 	peerCmd.AddCommand(joinCmd)
 	joinCmd.PersistentFlags().StringVar(&peerCfgFile, "config", "", "config file (required)")
 	joinCmd.MarkPersistentFlagRequired("config")
+	joinCmd.Flags().StringVar(&resumeSessionID, "resume", "", "resume an interrupted join using the session ID it was assigned")
+	joinCmd.Flags().StringVar(&relayCode, "relay-code", "", "pairing code from the host's `justsync server`, to rendezvous via session.host.relayUrl instead of dialing session.host.url directly")
+
+	// Register server prune sub-command
+	serverCmd.AddCommand(pruneCmd)
+
+	// Register the standalone relay command
+	rootCmd.AddCommand(relayCmd)
+	relayCmd.Flags().StringVar(&relayListenPort, "port", ":9000", "address for the relay to listen on")
+
+	// Register the standalone mount command
+	rootCmd.AddCommand(mountCmd)
+	mountCmd.Flags().StringVar(&mountPoint, "at", "", "directory to mount the project snapshot at (required)")
+	mountCmd.MarkFlagRequired("at")
 }
 
 func main() {
@@ -88,9 +159,20 @@ func main() {
 func runServer(cfg string) {
 	utils.CreateConfigFolderAt(utils.GetOsSpecificConfigPath())
 	config := utils.InitHostConfig(cfg)
+	snapshot.SetCompressionLevel(compress.Level(config.Application.CompressionLevel))
+	socket.ConfigureCompression(config.Application.WireCompression, config.Application.WireCompressionLevel)
 
 	http.HandleFunc("/connect", api.HandleConnectClient)
 	http.HandleFunc("/admin/generateOtp", api.HandleGenerateOtp)
+	http.HandleFunc("/admin/prune", api.HandlePrune)
+	http.HandleFunc("/admin/gc", api.HandleGC)
+	http.HandleFunc("/admin/metrics", api.HandleBlockCacheMetrics)
+	http.HandleFunc("/admin/bandwidth", api.HandleBandwidthMetrics)
+	http.HandleFunc("/events", api.HandleEvents)
+
+	if config.Application.RelayUrl != "" {
+		go runRelayRegistrationLoop(config.Application.RelayUrl)
+	}
 
 	utils.LogInfo("Server running at port %s", config.Application.Port)
 
@@ -99,41 +181,212 @@ func runServer(cfg string) {
 	}
 }
 
-// func runPeer(cfgName string) {
-// 	cfg := utils.InitClientConfig(cfgName)
-// 	host := "wss://" + cfg.Session.Host.Url + "/connect"
-// 	utils.LogInfo("Attempting to connect to: %s", host)
-//
-// 	conn, _, err := websocket.DefaultDialer.Dial(host, nil)
-// 	if err != nil {
-// 		utils.LogError("Could not dial %s due to error: %s", host, err.Error())
-// 		return
-// 	}
-// 	defer conn.Close()
-//
-// 	utils.LogInfo("Connection to host at %s established successfully", host)
-// 	utils.LogInfo("Attempting authentication handshake")
-//
-// 	err = conn.WriteMessage(websocket.TextMessage, []byte(cfg.Session.Client.Token))
-// 	if err != nil {
-// 		utils.LogError("Authentication token for handshake could not be sent: %s", err.Error())
-// 		return
-// 	}
-//
-// 	socket.SetHostConnection(conn)
-//
-// 	http.HandleFunc("/send-sync", api.RequestSync)
-// 	go service.KeepClientAlive(conn)
-// 	go service.HandleReceiveAndProcessIncomingMessages(conn)
-//
-// 	utils.LogInfo("Listening for sync requests on localhost port :10001")
-//
-// 	if err := http.ListenAndServe(cfg.Session.Port, nil); err != nil {
-// 		utils.LogError(err.Error())
-// 	}
+// runRelayRegistrationLoop mints a fresh pairing code, registers it with
+// the relay at relayUrl, and blocks serving whichever peer dials in under
+// it - then repeats, so `justsync server` stays reachable through the
+// relay across more than one pairing without needing to be restarted.
+func runRelayRegistrationLoop(relayUrl string) {
+	hub := socket.GetHub()
+	for {
+		code := utils.GetTokenManager().GeneratePairingCode()
+		utils.LogInfo("Relay pairing code (expires in %.0f minutes or first use): %s", utils.OtpExpiration.Minutes(), code)
+
+		if err := socket.ServeRelayHost(hub, relayUrl, code); err != nil {
+			utils.LogError("Relay pairing failed: %s", err.Error())
+		}
+	}
+}
+
+// runRelay runs a standalone public rendezvous relay: it never touches a
+// project snapshot or chunk store, only pipes bytes between a host
+// registered under a pairing code and the client that later dials in
+// with it (see JustSync/websocket's Relay). Anyone reachable from both a
+// host and its peers can run this, e.g. a small cloud VM, so neither side
+// needs to be reachable from the other directly.
+func runRelay(port string) {
+	relay := socket.NewRelay()
+	http.HandleFunc("/relay/host", relay.HandleHostRegister)
+	http.HandleFunc("/relay/join", relay.HandleClientDial)
+
+	utils.LogInfo("Relay listening at %s", port)
+	if err := http.ListenAndServe(port, nil); err != nil {
+		utils.LogError(err.Error())
+	}
+}
+
+// runPrune loads the on-disk project snapshot - nothing else in this
+// process has loaded it - then rebuilds the chunk store's refcount ledger
+// from it and deletes every blob it no longer references.
+func runPrune() {
+	if _, err := snapshot.LoadSnapshot(); err != nil {
+		utils.LogError("Could not load project snapshot, nothing to prune: %s", err.Error())
+		os.Exit(1)
+	}
+
+	removed, err := chunkstore.GC()
+	if err != nil {
+		utils.LogError("Prune failed: %s", err.Error())
+		os.Exit(1)
+	}
+
+	utils.LogInfo("Prune complete, removed %d unreferenced chunks", removed)
+}
+
+// runMount loads the on-disk project snapshot - there's no live host/join
+// session here to have populated it already - and serves it read-only at
+// mountpoint until the filesystem is unmounted or FUSE errors out.
+func runMount(mountpoint string) {
+	if _, err := snapshot.LoadSnapshot(); err != nil {
+		utils.LogError("Could not load project snapshot: %s", err.Error())
+		os.Exit(1)
+	}
+
+	if err := mount.Mount(mountpoint); err != nil {
+		utils.LogError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// dialHost establishes the connection a peer syncs against: a direct
+// dial plus PAKE handshake by default, or - when relayCode is non-empty -
+// a relay-paired, PAKE-encrypted tunnel (see JustSync/websocket's Relay
+// and EncryptedConn) instead. Either way the pairing code seeds a SPAKE2
+// exchange rather than crossing the wire itself, so a relay or a
+// man-in-the-middle on the direct path never sees it in plaintext.
+func dialHost(cfg utils.ExternalClientConfig, relayCode string) (socket.Conn, error) {
+	if relayCode != "" {
+		relayHost := "wss://" + cfg.Session.Host.RelayUrl + "/relay/join"
+		utils.LogInfo("Attempting to pair with host through relay at %s", relayHost)
+		return socket.DialRelayClient(relayHost, relayCode)
+	}
+
+	host := "wss://" + cfg.Session.Host.Url + "/connect"
+	utils.LogInfo("Attempting to connect to: %s", host)
+
+	conn, _, err := socket.Dialer.Dial(host, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial %s due to error: %w", host, err)
+	}
+
+	utils.LogInfo("Connection to host at %s established successfully", host)
+	utils.LogInfo("Attempting PAKE handshake")
+
+	encConn, err := socket.DialClientPake(conn, cfg.Session.Client.Token)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("PAKE handshake failed: %w", err)
+	}
+
+	return encConn, nil
+}
+
+// runHost connects to the configured host, then keeps the synced folder
+// continuously up to date: a filesystem watcher drives outgoing syncs as
+// files change locally, while incoming messages from the host are applied
+// in the background, so `justsync peer host` needs no separate client
+// poking /send-sync by hand.
+func runHost(cfgName string) {
+	cfg := utils.InitClientConfig(cfgName)
+	snapshot.SetCompressionLevel(compress.Level(cfg.Session.CompressionLevel))
+	socket.ConfigureCompression(cfg.Session.WireCompression, cfg.Session.WireCompressionLevel)
+
+	conn, err := dialHost(cfg, relayCode)
+	if err != nil {
+		utils.LogError(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	socket.SetHostConnection(conn)
+
+	http.HandleFunc("/send-sync", api.RequestSync)
+	http.HandleFunc("/events", api.HandleEvents)
+	go service.HandleReceiveAndProcessIncomingMessages(conn, socket.SendToHost)
+
+	root := filepath.Join(cfg.Session.Path, cfg.Session.Name)
+	exclude := append(append([]string{}, cfg.Session.Exclude...), cfg.Session.IgnoredFiles...)
+	match, err := filter.New(root, exclude, cfg.Session.Include)
+	if err != nil {
+		utils.LogError("Could not load %s for %s: %s", filter.IgnoreFileName, root, err.Error())
+	}
+	fsWatcher, err := watcher.New(root, match)
+	if err != nil {
+		utils.LogError("Could not start filesystem watcher for %s: %s", root, err.Error())
+	} else {
+		go fsWatcher.Run()
+		defer fsWatcher.Close()
+	}
+
+	utils.LogInfo("Listening for sync requests on %s", cfg.Session.Port)
+
+	if err := http.ListenAndServe(cfg.Session.Port, nil); err != nil {
+		utils.LogError(err.Error())
+	}
+
+	utils.LogWarn("Connection to host has been lost. Shutting down.")
+}
+
+// runJoin connects to the configured host as a plain peer, reports which
+// chunks this session already has verified on disk, and then receives the
+// sync the host sends back. Passing --resume picks an interrupted join
+// back up under its original session ID instead of starting over: the
+// host, told which chunks this peer's journal already verified, only
+// sends content for what's actually missing.
 //
-// 	utils.LogWarn("Connection to host has been lost. Shutting down.")
-// }
+// The connection itself is kept alive across drops: a failed dial or a
+// lost connection is retried with socket.DefaultBackoff instead of
+// exiting, and each reconnect's ResumeRequest carries both the chunks
+// already verified on disk and service.LastAckedSeq, so the host's
+// OutboundQueue can replay only what this peer hasn't seen yet rather
+// than re-running the whole sync.
+func runJoin(cfgName, resumeID string) {
+	cfg := utils.InitClientConfig(cfgName)
+	snapshot.SetCompressionLevel(compress.Level(cfg.Session.CompressionLevel))
+	socket.ConfigureCompression(cfg.Session.WireCompression, cfg.Session.WireCompressionLevel)
+
+	sessionID, haveChunks := service.BeginSession(resumeID)
+	if resumeID != "" {
+		utils.LogInfo("Resuming sync session %s (%d chunks already verified)", sessionID, len(haveChunks))
+	} else {
+		utils.LogInfo("Starting sync session %s - pass --resume %s to pick this up again if it's interrupted", sessionID, sessionID)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			wait := socket.DefaultBackoff.Next(attempt - 1)
+			utils.LogWarn("Reconnecting to host in %s (attempt %d)", wait, attempt)
+			time.Sleep(wait)
+		}
+
+		conn, err := dialHost(cfg, relayCode)
+		if err != nil {
+			utils.LogError(err.Error())
+			continue
+		}
+		socket.SetHostConnection(conn)
+
+		resumeMsg := snapshot.WebsocketMessage{
+			Payload: &snapshot.WebsocketMessage_ResumeRequest{
+				ResumeRequest: &snapshot.ResumeRequest{
+					SessionId:    sessionID,
+					HaveChunks:   haveChunks,
+					LastAckedSeq: service.LastAckedSeq(),
+				},
+			},
+		}
+		if err := socket.SendToHost(&resumeMsg); err != nil {
+			utils.LogError("Could not send resume request to host: %s", err.Error())
+			conn.Close()
+			continue
+		}
+
+		ackStop := make(chan struct{})
+		go socket.StartAckLoop(ackStop)
+		service.HandleReceiveAndProcessIncomingMessages(conn, socket.SendToHost)
+		close(ackStop)
+		conn.Close()
+	}
+}
 
 func runAdminMode() {
 	utils.LogInfo("Admin console")
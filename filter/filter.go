@@ -0,0 +1,161 @@
+// Package filter implements gitignore-style include/exclude matching for
+// project traversal, in the spirit of rclone's filter engine: a project's
+// .justsyncignore file and a config's exclude/include lists all compile
+// down into one ordered *Matcher, which both the host's project walk and
+// the peer's filesystem watcher consume instead of each rolling their own
+// filepath.Match loop.
+package filter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the project-root file Load reads, analogous to a
+// .gitignore.
+const IgnoreFileName = ".justsyncignore"
+
+// rule is one compiled line of gitignore syntax: a leading "!" negates it,
+// a trailing "/" restricts it to directories, and "**" matches across path
+// separators.
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher is a compiled, ordered set of gitignore-style rules. As in git,
+// the last rule to match a path decides its fate, so a later include can
+// carve an exception out of an earlier broad exclude.
+type Matcher struct {
+	rules []rule
+}
+
+// Compile builds a Matcher directly from gitignore-syntax lines, without
+// reading anything from disk.
+func Compile(lines []string) *Matcher {
+	m := &Matcher{}
+	m.addLines(lines)
+	return m
+}
+
+// Load reads root's .justsyncignore file into a Matcher. A missing file
+// compiles to an empty, match-nothing Matcher rather than an error, since
+// not every project opts into one.
+func Load(root string) (*Matcher, error) {
+	data, err := os.ReadFile(filepath.Join(root, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Matcher{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Compile(strings.Split(string(data), "\n")), nil
+}
+
+// New builds root's Matcher from its .justsyncignore file plus exclude and
+// include patterns from a session/application config: exclude patterns
+// behave as ordinary ignore rules, include patterns as negations applied
+// after them, so a config can claw back a path the ignore file or an
+// exclude list would otherwise drop.
+func New(root string, exclude, include []string) (*Matcher, error) {
+	m, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	m.addLines(exclude)
+	for _, pattern := range include {
+		m.addLines([]string{"!" + pattern})
+	}
+	return m, nil
+}
+
+func (m *Matcher) addLines(lines []string) {
+	for _, line := range lines {
+		if r, ok := compileLine(line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+func compileLine(line string) (rule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// A pattern containing a slash (other than a trailing one already
+	// stripped above) is anchored to root, matching gitignore's rule that
+	// "/foo" and "a/b" only match at that exact location, while a bare
+	// "foo" matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	body := translateGlob(line)
+	pattern := "^" + body + "$"
+	if !anchored {
+		pattern = "^(.*/)?" + body + "$"
+	}
+	r.re = regexp.MustCompile(pattern)
+	return r, true
+}
+
+// translateGlob turns a gitignore glob into the body of a regexp: "**"
+// matches any number of path segments (including none), "*" matches within
+// a single segment, and "?" matches a single non-separator character.
+func translateGlob(pattern string) string {
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				sb.WriteString("(.*/)?")
+				i++
+			} else {
+				sb.WriteString(".*")
+			}
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// Match reports whether relPath - slash-separated and relative to the
+// project root - should be excluded from traversal. isDir lets a
+// directory-only rule ("build/") match the directory itself without also
+// matching a same-named file elsewhere.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(strings.TrimPrefix(relPath, "/"))
+
+	matched := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(relPath) {
+			matched = !r.negate
+		}
+	}
+	return matched
+}
@@ -1,3 +1,10 @@
+// Package config is the single canonical configuration schema for
+// JustSync, used by both server (host) and peer (client) processes. It
+// replaces the previous split between this package and utils' own
+// ExternalClientConfig/ExternalHostConfig types, which had drifted apart
+// (duplicate singletons, a PathToCloneTo/PathToCloneFrom pair sharing one
+// YAML key). utils.GetClientConfig/utils.GetHostConfig and friends now
+// forward here so existing call sites keep working unmodified.
 package config
 
 import (
@@ -14,41 +21,12 @@ import (
 
 type RunMode string
 
-type PeerConfig struct {
-	Session struct {
-		Port            string   `yaml:"port"`
-		Name            string   `yaml:"name"`
-		PathToCloneTo   string   `yaml:"path"`
-		PathToCloneFrom string   `yaml:"path"`
-		IgnoredFiles    []string `yaml:"ignoredFiles"`
-		Client          struct {
-			Name  string `yaml:"name"`
-			Token string `yaml:"token"`
-		}
-	}
-}
-
-type ServerConfig struct {
-	Application struct {
-		Port string `yaml:"port"`
-	}
-}
-
 const (
 	ServerMode RunMode = "server"
 	ClientMode RunMode = "client"
 	AdminMode  RunMode = "admin"
 )
 
-var (
-	mode RunMode
-
-	hostConfig      ServerConfig
-	hostSingleton   sync.Once
-	clientConfig    PeerConfig
-	clientSingleton sync.Once
-)
-
 func (m *RunMode) String() string {
 	return string(*m)
 }
@@ -67,6 +45,152 @@ func (m *RunMode) Set(value string) error {
 	return nil
 }
 
+// FolderConfig is a single synced folder and its per-folder overrides, in
+// the spirit of Syncthing's .stfolder/.stignore - each folder can ignore
+// its own set of paths and pick its own versioning policy independently of
+// the session/application defaults.
+type FolderConfig struct {
+	Path             string   `yaml:"path"`
+	IgnoredFiles     []string `yaml:"ignoredFiles"`
+	VersioningPolicy string   `yaml:"versioningPolicy"` // "", "trash", "simple", "staggered"
+	// CollabMode routes this folder's files through the Y.Doc CRDT sync
+	// path (see internal/service/sync) instead of the default
+	// chunk-diffing CDC path, so concurrent edits from multiple peers
+	// converge instead of racing each other's deltas.
+	CollabMode bool `yaml:"collabMode"`
+}
+
+// PeerConfig is the peer (client) side configuration: which session to join
+// and where to materialize it locally.
+type PeerConfig struct {
+	Session struct {
+		Port         string   `yaml:"port"`
+		Name         string   `yaml:"name"`
+		Path         string   `yaml:"path"`
+		IgnoredFiles []string `yaml:"ignoredFiles"`
+		// CompressionLevel controls zstd compression of snapshot files and
+		// wire chunks: 0=fastest, 1=default, 2=better, 3=best. Matches the
+		// ordinals of utils/compress.Level.
+		CompressionLevel int `yaml:"compressionLevel"`
+		// Exclude and Include are gitignore-style patterns (see package
+		// filter) layered on top of the project's .justsyncignore file:
+		// Exclude patterns are additional ignore rules, Include patterns
+		// are negations applied after them.
+		Exclude []string `yaml:"exclude"`
+		Include []string `yaml:"include"`
+		// MaxFileSizeBytes skips any file larger than this during traversal
+		// and rejects one pushed by a peer anyway. Zero means unbounded.
+		MaxFileSizeBytes int64 `yaml:"maxFileSizeBytes"`
+		// FollowSymlinks controls whether project traversal descends into
+		// symlinked files and directories instead of skipping them. Off by
+		// default, since following one can walk outside the project root
+		// or loop forever on a cycle.
+		FollowSymlinks bool `yaml:"followSymlinks"`
+		// WireCompression negotiates the permessage-deflate WebSocket
+		// extension on the sync connection, trading CPU for bandwidth on
+		// highly compressible payloads like InitialFile and
+		// FileDelta.AddedChunks. Off by default.
+		WireCompression bool `yaml:"wireCompression"`
+		// WireCompressionLevel sets the deflate level used when
+		// WireCompression is on, 1 (fastest) through 9 (best); zero picks
+		// flate's own default.
+		WireCompressionLevel int `yaml:"wireCompressionLevel"`
+		// RollingDeltaEncoding makes SyncFile exchange a librsync-style
+		// rolling-hash signature of the basis version instead of relying
+		// solely on CDC chunk-hash matches, so an edit that shifts content
+		// within a chunk still transmits as a handful of literal bytes
+		// rather than the whole surrounding chunk. Off by default, since
+		// computing and matching the signature costs CPU the plain CDC
+		// path doesn't pay.
+		RollingDeltaEncoding bool `yaml:"rollingDeltaEncoding"`
+		// RateLimitBytesPerSec caps how fast this peer's connection reads
+		// and writes marshaled protobuf frames, each direction metered
+		// independently by its own token bucket (see service/ratelimit).
+		// Zero means unbounded.
+		RateLimitBytesPerSec int64 `yaml:"rateLimitBytesPerSec"`
+		Host                 struct {
+			Url string `yaml:"url"`
+			// RelayUrl, if set, is dialed instead of Url when --relay-code
+			// is passed on the command line: the peer rendezvous with the
+			// host through a Relay (see JustSync/websocket) rather than
+			// dialing Url directly, for hosts unreachable over the
+			// network (e.g. both sides behind NAT).
+			RelayUrl string `yaml:"relayUrl"`
+		}
+		Client struct {
+			Name string `yaml:"name"`
+			// Token is the pairing code the host's /admin/generateOtp
+			// printed - it seeds this peer's side of the PAKE handshake
+			// in cmd's dialHost rather than crossing the wire itself, so
+			// it must match whatever code is currently live on the host.
+			Token string `yaml:"token"`
+		}
+	}
+	Folders []FolderConfig `yaml:"folders"`
+}
+
+// ServerConfig is the server (host) side configuration: where it listens
+// and the project it serves.
+type ServerConfig struct {
+	Application struct {
+		Port         string   `yaml:"port"`
+		Path         string   `yaml:"path"`
+		IgnoredFiles []string `yaml:"ignoredFiles"`
+		// CompressionLevel controls zstd compression of snapshot files and
+		// wire chunks: 0=fastest, 1=default, 2=better, 3=best. Matches the
+		// ordinals of utils/compress.Level.
+		CompressionLevel int `yaml:"compressionLevel"`
+		// Exclude and Include are gitignore-style patterns (see package
+		// filter) layered on top of the project's .justsyncignore file:
+		// Exclude patterns are additional ignore rules, Include patterns
+		// are negations applied after them.
+		Exclude []string `yaml:"exclude"`
+		Include []string `yaml:"include"`
+		// MaxFileSizeBytes skips any file larger than this during traversal
+		// and rejects one pushed by a peer anyway. Zero means unbounded.
+		MaxFileSizeBytes int64 `yaml:"maxFileSizeBytes"`
+		// FollowSymlinks controls whether project traversal descends into
+		// symlinked files and directories instead of skipping them. Off by
+		// default, since following one can walk outside the project root
+		// or loop forever on a cycle.
+		FollowSymlinks bool `yaml:"followSymlinks"`
+		// WireCompression negotiates the permessage-deflate WebSocket
+		// extension on the sync connection, trading CPU for bandwidth on
+		// highly compressible payloads like InitialFile and
+		// FileDelta.AddedChunks. Off by default.
+		WireCompression bool `yaml:"wireCompression"`
+		// WireCompressionLevel sets the deflate level used when
+		// WireCompression is on, 1 (fastest) through 9 (best); zero picks
+		// flate's own default.
+		WireCompressionLevel int `yaml:"wireCompressionLevel"`
+		// RollingDeltaEncoding mirrors PeerConfig's field of the same name.
+		// SyncFile reads only the peer config's flag, so setting it here
+		// has no effect on the deltas SyncFile builds.
+		RollingDeltaEncoding bool `yaml:"rollingDeltaEncoding"`
+		// RateLimitBytesPerSec caps how fast each connected peer's
+		// connection reads and writes marshaled protobuf frames, each
+		// direction metered independently by its own token bucket (see
+		// JustSync/websocket's Client). Zero means unbounded.
+		RateLimitBytesPerSec int64 `yaml:"rateLimitBytesPerSec"`
+		// RelayUrl, if set, makes `justsync server` additionally register
+		// a pairing code with this Relay (see JustSync/websocket) between
+		// connections, so a peer that can't reach Port directly can still
+		// pair with this host through it.
+		RelayUrl string `yaml:"relayUrl"`
+	}
+	Folders []FolderConfig `yaml:"folders"`
+}
+
+var (
+	mode RunMode
+
+	mu              sync.RWMutex
+	hostConfig      ServerConfig
+	hostSingleton   sync.Once
+	clientConfig    PeerConfig
+	clientSingleton sync.Once
+)
+
 func GetMode() *RunMode {
 	return &mode
 }
@@ -75,62 +199,108 @@ func SetMode(m RunMode) {
 	mode = m
 }
 
+// InitHostConfig loads and validates the named host config, exiting the
+// process on a bad config rather than silently continuing with a
+// zero-value struct the way the previous two config packages did.
 func InitHostConfig(cfgName string) ServerConfig {
 	hostSingleton.Do(func() {
-		hostConfig = GetExternalHostConfig(cfgName)
+		cfg, err := loadHostConfig(cfgName)
+		if err != nil {
+			pkg.LogError("Invalid host config '%s': %s", cfgName, err.Error())
+			os.Exit(1)
+		}
+		hostConfig = cfg
 	})
 
-	return hostConfig
+	return GetHostConfig()
 }
 
 func GetHostConfig() ServerConfig {
+	mu.RLock()
+	defer mu.RUnlock()
 	return hostConfig
 }
 
+// InitClientConfig loads and validates the named peer config, exiting the
+// process on a bad config rather than silently continuing with a
+// zero-value struct the way the previous two config packages did.
 func InitClientConfig(cfgName string) PeerConfig {
 	clientSingleton.Do(func() {
-		clientConfig = GetExternalClientConfig(cfgName)
+		cfg, err := loadClientConfig(cfgName)
+		if err != nil {
+			pkg.LogError("Invalid peer config '%s': %s", cfgName, err.Error())
+			os.Exit(1)
+		}
+		clientConfig = cfg
 	})
 
-	return clientConfig
+	return GetClientConfig()
 }
 
 func GetClientConfig() PeerConfig {
+	mu.RLock()
+	defer mu.RUnlock()
 	return clientConfig
 }
 
-func GetExternalClientConfig(name string) PeerConfig {
-	var config PeerConfig
+func loadClientConfig(name string) (PeerConfig, error) {
+	var cfg PeerConfig
 	path := filepath.Join(GetOsSpecificConfigPath(), name+".yml")
-	configContent, err := os.ReadFile(path)
+	content, err := os.ReadFile(path)
 	if err != nil {
-		pkg.LogError("Config '%s' not found at os' specific config path '%s'", name, path)
-		return config
+		return cfg, fmt.Errorf("config '%s' not found at '%s': %w", name, path, err)
 	}
 
-	if err = yaml.Unmarshal(configContent, &config); err != nil {
-		pkg.LogError("Error in config '%s' found. Could not parse config.", name)
-		return config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not parse config '%s': %w", name, err)
 	}
 
-	return config
+	if cfg.Session.Path == "" {
+		return cfg, fmt.Errorf("config '%s': session.path is required", name)
+	}
+	if cfg.Session.Name == "" {
+		return cfg, fmt.Errorf("config '%s': session.name is required", name)
+	}
+
+	return cfg, nil
 }
 
-func GetExternalHostConfig(name string) ServerConfig {
-	var config ServerConfig
+func loadHostConfig(name string) (ServerConfig, error) {
+	var cfg ServerConfig
 	path := filepath.Join(GetOsSpecificConfigPath(), name+".yml")
-	configContent, err := os.ReadFile(path)
+	content, err := os.ReadFile(path)
 	if err != nil {
-		pkg.LogError("Config '%s' not found at os' specific config path '%s'", name, path)
-		return config
+		return cfg, fmt.Errorf("config '%s' not found at '%s': %w", name, path, err)
+	}
+
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not parse config '%s': %w", name, err)
+	}
+
+	if cfg.Application.Port == "" {
+		return cfg, fmt.Errorf("config '%s': application.port is required", name)
 	}
 
-	if err = yaml.Unmarshal(configContent, &config); err != nil {
-		pkg.LogError("Error in config '%s' found. Could not parse config.", name)
-		return config
+	return cfg, nil
+}
+
+// GetExternalClientConfig and GetExternalHostConfig are kept for callers
+// that want to parse a config without going through the process-wide
+// singleton (e.g. the CLI --config flag validation).
+func GetExternalClientConfig(name string) PeerConfig {
+	cfg, err := loadClientConfig(name)
+	if err != nil {
+		pkg.LogError(err.Error())
 	}
+	return cfg
+}
 
-	return config
+func GetExternalHostConfig(name string) ServerConfig {
+	cfg, err := loadHostConfig(name)
+	if err != nil {
+		pkg.LogError(err.Error())
+	}
+	return cfg
 }
 
 func GetOsSpecificConfigPath() string {
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"JustSync/pkg"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchHostConfig re-reads the named host config whenever its YAML file
+// changes on disk, replacing the process-wide singleton returned by
+// GetHostConfig and invoking onChange. It returns the fsnotify.Watcher so
+// the caller can Close it on shutdown; watch failures are logged and
+// non-fatal since the process can keep running on the last good config.
+func WatchHostConfig(cfgName string, onChange func(ServerConfig)) (*fsnotify.Watcher, error) {
+	return watchConfig(cfgName, func(path string) {
+		cfg, err := loadHostConfig(cfgName)
+		if err != nil {
+			pkg.LogError("Config '%s' changed but failed to reload: %s", path, err.Error())
+			return
+		}
+
+		mu.Lock()
+		hostConfig = cfg
+		mu.Unlock()
+
+		pkg.LogInfo("Reloaded host config '%s'", cfgName)
+		if onChange != nil {
+			onChange(cfg)
+		}
+	})
+}
+
+// WatchClientConfig is the peer-side counterpart of WatchHostConfig.
+func WatchClientConfig(cfgName string, onChange func(PeerConfig)) (*fsnotify.Watcher, error) {
+	return watchConfig(cfgName, func(path string) {
+		cfg, err := loadClientConfig(cfgName)
+		if err != nil {
+			pkg.LogError("Config '%s' changed but failed to reload: %s", path, err.Error())
+			return
+		}
+
+		mu.Lock()
+		clientConfig = cfg
+		mu.Unlock()
+
+		pkg.LogInfo("Reloaded peer config '%s'", cfgName)
+		if onChange != nil {
+			onChange(cfg)
+		}
+	})
+}
+
+func watchConfig(cfgName string, reload func(path string)) (*fsnotify.Watcher, error) {
+	path := filepath.Join(GetOsSpecificConfigPath(), cfgName+".yml")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				reload(path)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				pkg.LogError("Config watcher error: %s", err.Error())
+			}
+		}
+	}()
+
+	return watcher, nil
+}
@@ -0,0 +1,86 @@
+// Package identity manages this node's long-lived device identity: an
+// Ed25519 keypair persisted next to the YAML config, and the short,
+// human-comparable DeviceID derived from its public key (in the spirit of
+// Syncthing's device IDs). Device pairing and trust (deciding which
+// DeviceIDs a folder accepts connections from) are handled elsewhere; this
+// package only owns "who am I".
+package identity
+
+import (
+	"JustSync/internal/config"
+	"JustSync/pkg"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const keyFileName = "identity.key"
+
+// ErrCorruptKey is returned when the persisted identity file is present but
+// not a valid Ed25519 private key.
+var ErrCorruptKey = errors.New("identity: persisted key file is corrupt")
+
+// DeviceID uniquely identifies a device by the unpadded base32 encoding of
+// its Ed25519 public key, e.g. the string peers compare during pairing.
+type DeviceID string
+
+// Identity is this node's keypair.
+type Identity struct {
+	Public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+// DeviceID derives this identity's DeviceID from its public key.
+func (i *Identity) DeviceID() DeviceID {
+	return DeviceID(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(i.Public))
+}
+
+// Sign signs msg with this identity's private key.
+func (i *Identity) Sign(msg []byte) []byte {
+	return ed25519.Sign(i.private, msg)
+}
+
+var (
+	current *Identity
+	once    sync.Once
+	loadErr error
+)
+
+// Load returns this node's Identity, generating and persisting a new
+// keypair next to the YAML config on first run. Subsequent calls return the
+// same in-memory Identity.
+func Load() (*Identity, error) {
+	once.Do(func() {
+		current, loadErr = loadOrCreate()
+	})
+	return current, loadErr
+}
+
+func loadOrCreate() (*Identity, error) {
+	path := filepath.Join(config.GetOsSpecificConfigPath(), keyFileName)
+
+	if data, err := os.ReadFile(path); err == nil {
+		if len(data) != ed25519.PrivateKeySize {
+			return nil, ErrCorruptKey
+		}
+		priv := ed25519.PrivateKey(data)
+		pub, _ := priv.Public().(ed25519.PublicKey)
+		return &Identity{Public: pub, private: priv}, nil
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(path, priv, 0600); err != nil {
+		return nil, err
+	}
+
+	pkg.LogInfo("Generated new device identity")
+	return &Identity{Public: pub, private: priv}, nil
+}
@@ -0,0 +1,39 @@
+package sync
+
+// SetAwareness records peerID's awareness payload - e.g. an encoded
+// cursor/selection position - against relativePath, replacing whatever
+// that peer last reported. No wire message carries awareness yet; this is
+// the storage side a future broadcast message can call into.
+func (s *SyncService) SetAwareness(relativePath, peerID string, payload []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.awareness[relativePath] == nil {
+		s.awareness[relativePath] = make(map[string][]byte)
+	}
+	s.awareness[relativePath][peerID] = payload
+}
+
+// ClearAwareness drops peerID's awareness state across every document,
+// e.g. once it disconnects.
+func (s *SyncService) ClearAwareness(peerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, peers := range s.awareness {
+		delete(peers, peerID)
+	}
+}
+
+// Awareness returns a snapshot of every peer's reported awareness state
+// for relativePath.
+func (s *SyncService) Awareness(relativePath string) map[string][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string][]byte, len(s.awareness[relativePath]))
+	for id, payload := range s.awareness[relativePath] {
+		out[id] = payload
+	}
+	return out
+}
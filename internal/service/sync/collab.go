@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"JustSync/snapshot"
+)
+
+// BuildSyncStep1Messages returns one YSyncStep1 message per collaborative
+// document this service currently holds, for a newly connected peer to
+// send as the opening move of the y-protocols sync handshake: "here's
+// what I already have, send me what I'm missing".
+func (s *SyncService) BuildSyncStep1Messages() []snapshot.WebsocketMessage {
+	s.mu.Lock()
+	paths := make([]string, 0, len(s.documents))
+	for path := range s.documents {
+		paths = append(paths, path)
+	}
+	s.mu.Unlock()
+
+	messages := make([]snapshot.WebsocketMessage, 0, len(paths))
+	for _, path := range paths {
+		messages = append(messages, snapshot.WebsocketMessage{
+			Payload: &snapshot.WebsocketMessage_YSyncStep1{
+				YSyncStep1: &snapshot.YSyncStep1{
+					Path:        []byte(path),
+					StateVector: s.EncodeStateVector(path),
+				},
+			},
+		})
+	}
+	return messages
+}
+
+// HandleYSyncStep1 replies to a peer's state vector with whatever update
+// it's missing - the YSyncStep2 half of the y-protocols handshake.
+func (s *SyncService) HandleYSyncStep1(msg *snapshot.YSyncStep1) snapshot.WebsocketMessage {
+	path := string(msg.Path)
+	update := s.EncodeStateAsUpdate(path, msg.StateVector)
+
+	return snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_YSyncStep2{
+			YSyncStep2: &snapshot.YSyncStep2{
+				Path:   msg.Path,
+				Update: update,
+			},
+		},
+	}
+}
+
+// HandleYSyncStep2 applies the catch-up update a peer replied with after
+// our own YSyncStep1.
+func (s *SyncService) HandleYSyncStep2(msg *snapshot.YSyncStep2) error {
+	return s.ApplyUpdate(string(msg.Path), msg.Update)
+}
+
+// HandleYUpdate applies an incremental update a peer broadcast after the
+// initial handshake - an ordinary collaborative edit.
+func (s *SyncService) HandleYUpdate(msg *snapshot.YUpdate) error {
+	return s.ApplyUpdate(string(msg.Path), msg.Update)
+}
+
+// BuildYUpdateMessage wraps update - as already computed by the caller,
+// e.g. a diff against the state vector before a local edit - for
+// broadcast to every other peer editing relativePath.
+func BuildYUpdateMessage(relativePath string, update []byte) snapshot.WebsocketMessage {
+	return snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_YUpdate{
+			YUpdate: &snapshot.YUpdate{
+				Path:   []byte(relativePath),
+				Update: update,
+			},
+		},
+	}
+}
@@ -1,9 +1,17 @@
 package sync
 
 import (
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+
 	y "github.com/skyterra/y-crdt"
 )
 
+// collabDir is where persisted Y.Doc state lives, alongside the project
+// snapshot itself.
+const collabDir = "snapshot/collab"
+
 func CreateDocFromContent(relativePath string, content []byte) *y.Doc {
 	doc := y.NewDoc(relativePath, false, nil, nil, false)
 	text := doc.GetText("content")
@@ -22,3 +30,133 @@ func EncodeStateFromDisk(docs map[string]*y.Doc) ([]byte, error) {
 	mergedPayload := y.MergeUpdatesV2(individualUpdates, y.NewUpdateDecoderV1, y.NewUpdateEncoderV1, true)
 	return mergedPayload, nil
 }
+
+// EncodeStateVector returns relativePath's current Y.Doc state vector -
+// the compact summary of what this side already has that opens the
+// y-protocols sync handshake as a YSyncStep1 message.
+func (s *SyncService) EncodeStateVector(relativePath string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := s.docForPath(relativePath)
+	return y.EncodeStateVector(doc, nil, y.NewUpdateEncoderV1())
+}
+
+// EncodeStateAsUpdate returns the update relativePath's Y.Doc needs to
+// apply to catch a peer up from sv, that peer's own state vector - the
+// YSyncStep2 reply to its YSyncStep1.
+func (s *SyncService) EncodeStateAsUpdate(relativePath string, sv []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := s.docForPath(relativePath)
+	return y.EncodeStateAsUpdate(doc, sv)
+}
+
+// ApplyUpdate merges update into relativePath's Y.Doc - a YSyncStep2 reply
+// to our own YSyncStep1, or a later YUpdate broadcast - and persists the
+// doc's resulting state to disk so a restart can rejoin without replaying
+// every update a peer ever sent.
+func (s *SyncService) ApplyUpdate(relativePath string, update []byte) error {
+	s.mu.Lock()
+	doc := s.docForPath(relativePath)
+	y.ApplyUpdate(doc, update, nil)
+	s.mu.Unlock()
+
+	return persistDocState(relativePath, doc)
+}
+
+// ApplyLocalEdit merges a local, on-disk edit of relativePath's content
+// into its Y.Doc - diffing against the doc's current text so only the
+// actually-changed middle becomes an Insert/Delete, rather than replacing
+// the whole document - and returns the resulting update for the caller to
+// broadcast as a YUpdate. Returns a nil update if content didn't actually
+// change, mirroring SyncFile's ErrNoChange contract for the CDC path.
+//
+// The diff itself runs over UTF-16 code units, not bytes: YText.Insert/
+// Delete index into the doc the same way Y.js does, by UTF-16 code unit
+// (see content_string.go/utils.go in y-crdt), so a byte-offset diff would
+// misplace every edit past the first multi-byte rune and could split a
+// surrogate pair outright.
+func (s *SyncService) ApplyLocalEdit(relativePath string, content []byte) ([]byte, error) {
+	s.mu.Lock()
+	doc := s.docForPath(relativePath)
+	text := doc.GetText("content")
+
+	before := y.EncodeStateVector(doc, nil, y.NewUpdateEncoderV1())
+	old, updated := text.ToString(), string(content)
+	if old == updated {
+		s.mu.Unlock()
+		return nil, nil
+	}
+
+	oldUnits, updatedUnits := utf16.Encode([]rune(old)), utf16.Encode([]rune(updated))
+	prefix := commonPrefixLen(oldUnits, updatedUnits)
+	suffix := commonSuffixLen(oldUnits[prefix:], updatedUnits[prefix:])
+
+	if removed := len(oldUnits) - prefix - suffix; removed > 0 {
+		text.Delete(prefix, removed)
+	}
+	if inserted := updatedUnits[prefix : len(updatedUnits)-suffix]; len(inserted) > 0 {
+		text.Insert(prefix, string(utf16.Decode(inserted)), nil)
+	}
+
+	update := y.EncodeStateAsUpdate(doc, before)
+	s.mu.Unlock()
+
+	if err := persistDocState(relativePath, doc); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// commonPrefixLen returns how many leading UTF-16 code units a and b
+// share.
+func commonPrefixLen(a, b []uint16) int {
+	n := min(len(a), len(b))
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// commonSuffixLen returns how many trailing UTF-16 code units a and b
+// share.
+func commonSuffixLen(a, b []uint16) int {
+	n := min(len(a), len(b))
+	i := 0
+	for i < n && a[len(a)-1-i] == b[len(b)-1-i] {
+		i++
+	}
+	return i
+}
+
+// persistDocState writes relativePath's current merged Y.Doc state to
+// disk, sharded by path under collabDir the same way chunkstore shards
+// blobs by hash.
+func persistDocState(relativePath string, doc *y.Doc) error {
+	target := docStatePath(relativePath)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, y.EncodeStateAsUpdate(doc, nil), 0644)
+}
+
+// loadPersistedDocState reads relativePath's persisted Y.Doc state back
+// in, if any, applying it to a fresh doc instead of CreateDocFromContent
+// so a prior collaborative session's history survives a restart.
+func loadPersistedDocState(relativePath string) (doc *y.Doc, restored bool) {
+	data, err := os.ReadFile(docStatePath(relativePath))
+	if err != nil {
+		return nil, false
+	}
+
+	doc = y.NewDoc(relativePath, false, nil, nil, false)
+	y.ApplyUpdate(doc, data, nil)
+	return doc, true
+}
+
+func docStatePath(relativePath string) string {
+	return filepath.Join(collabDir, relativePath+".ydoc")
+}
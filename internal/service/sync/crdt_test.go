@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+func units(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+func TestCommonPrefixSuffixLenASCII(t *testing.T) {
+	a, b := units("hello world"), units("hello there")
+	if got := commonPrefixLen(a, b); got != 6 {
+		t.Errorf("commonPrefixLen(%q, %q) = %d, want 6", "hello world", "hello there", got)
+	}
+	if got := commonSuffixLen(a, b); got != 0 {
+		t.Errorf("commonSuffixLen(%q, %q) = %d, want 0", "hello world", "hello there", got)
+	}
+}
+
+// TestCommonPrefixSuffixLenSurrogatePair exercises a rune (an emoji) that
+// encodes to a UTF-16 surrogate pair - two code units - to confirm the
+// diff walks code units, not bytes or runes, matching what
+// YText.Insert/Delete actually index by.
+func TestCommonPrefixSuffixLenSurrogatePair(t *testing.T) {
+	old := "abc\U0001F600def"     // "abc" + 😀 (2 UTF-16 units) + "def"
+	updated := "abc\U0001F600xyz" // same prefix including the emoji, different suffix
+
+	oldUnits, updatedUnits := units(old), units(updated)
+	prefix := commonPrefixLen(oldUnits, updatedUnits)
+	if want := len(units("abc\U0001F600")); prefix != want {
+		t.Fatalf("commonPrefixLen = %d, want %d (must include the full surrogate pair)", prefix, want)
+	}
+
+	suffix := commonSuffixLen(oldUnits[prefix:], updatedUnits[prefix:])
+	if suffix != 0 {
+		t.Errorf("commonSuffixLen = %d, want 0", suffix)
+	}
+
+	// Reassembling prefix+middle+suffix back to runes must not split the
+	// surrogate pair in two.
+	decoded := utf16.Decode(oldUnits[:prefix])
+	if string(decoded) != "abc\U0001F600" {
+		t.Errorf("decoding the shared prefix split the surrogate pair: got %q", string(decoded))
+	}
+}
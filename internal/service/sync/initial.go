@@ -1,8 +1,12 @@
 package sync
 
 import (
-	snapshot "JustSync/api"
+	"JustSync/internal/config"
 	"JustSync/pkg"
+	"JustSync/service/chunkstore"
+	"JustSync/snapshot"
+	"JustSync/snapshot/chunked"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -13,14 +17,14 @@ import (
 func (s *SyncService) hydrateFromDisk(service SyncService) error {
 	pkg.LogInfo("Initializing service state from disk...")
 
-	return filepath.WalkDir(s.config.Session.PathToCloneFrom, func(path string, d fs.DirEntry, err error) error {
+	return filepath.WalkDir(s.config.Session.Path, func(path string, d fs.DirEntry, err error) error {
 		content, err := os.ReadFile(path)
 		if err != nil {
 			pkg.LogError("Failed to read file %s: %v", path, err)
 			return err
 		}
 
-		relativePath, _ := filepath.Rel(s.config.Session.PathToCloneFrom, path)
+		relativePath, _ := filepath.Rel(s.config.Session.Path, path)
 		doc := CreateDocFromContent(relativePath, content)
 		s.documents[relativePath] = doc
 
@@ -29,7 +33,7 @@ func (s *SyncService) hydrateFromDisk(service SyncService) error {
 }
 
 func PrepareReceiveProjectSync() error {
-	cfg := utils.GetClientConfig()
+	cfg := config.GetClientConfig()
 	path := filepath.Join(cfg.Session.Path, cfg.Session.Name)
 
 	// Check if destination path already exists
@@ -55,10 +59,20 @@ func PrepareReceiveProjectSync() error {
 	return nil
 }
 
-// ProcessNewFileSync builds up a file at a given path and fills it with the desired content
+// ProcessNewFileSync builds up a file at a given path, pulling only the
+// chunks it doesn't already hold in the local chunk store.
+//
+// Rather than writing inline chunk bytes carried on the websocket message
+// (the previous, service/sync.go behavior), msg.InitialFile now carries
+// the file's chunked.TOC (see snapshot/chunked) plus the URL of the blob
+// it describes. ProcessNewFileSync decodes the TOC, diffs its hashes
+// against chunkstore, and range-fetches only the entries that are
+// missing - so a fresh `justsync peer join` no longer re-downloads
+// content this peer already has for an unrelated file sharing the same
+// chunk.
 func ProcessNewFileSync(syncService SyncService, msg snapshot.WebsocketMessage_InitialFile) error {
 	// Build the path for the new file
-	cfg := utils.GetClientConfig()
+	cfg := config.GetClientConfig()
 	path := filepath.Join(cfg.Session.Path, cfg.Session.Name, string(msg.InitialFile.Path))
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -66,6 +80,15 @@ func ProcessNewFileSync(syncService SyncService, msg snapshot.WebsocketMessage_I
 		return err
 	}
 
+	toc, err := chunked.DecodeTOC(msg.InitialFile.Toc)
+	if err != nil {
+		pkg.LogError("Could not decode chunk TOC for '%s' due to: %s", msg.InitialFile.Path, err.Error())
+		return err
+	}
+
+	fetcher := chunked.NewHTTPFetcher(msg.InitialFile.BlobUrl, nil)
+	reader := chunked.NewChunkedReader(fetcher, toc)
+
 	// Create the actual file
 	file, err := os.Create(path)
 	if err != nil {
@@ -74,19 +97,40 @@ func ProcessNewFileSync(syncService SyncService, msg snapshot.WebsocketMessage_I
 	}
 	defer file.Close()
 
-	// Fill the file with the actual content
-	totalWrittenBytes := 0
-	for _, chunk := range msg.InitialFile.File.Chunks {
-		b, err := file.WriteAt(chunk.Content, chunk.Offset)
+	// Fill the file, fetching only the entries this peer doesn't already
+	// hold for some other file.
+	var totalWrittenBytes, fetchedChunks int
+	for _, entry := range toc.Entries {
+		hash, err := hex.DecodeString(entry.Hash)
+		if err != nil {
+			pkg.LogError("Invalid chunk hash '%s' in TOC for '%s': %s", entry.Hash, msg.InitialFile.Path, err.Error())
+			return err
+		}
+
+		var data []byte
+		if chunkstore.Has(hash) {
+			data, err = chunkstore.Get(hash)
+		} else {
+			data, err = reader.FetchChunk(entry)
+			if err == nil {
+				err = chunkstore.Put(hash, data)
+			}
+			fetchedChunks++
+		}
+		if err != nil {
+			pkg.LogError("Could not obtain chunk %s for file '%s' due to: %s", entry.Hash, msg.InitialFile.Path, err.Error())
+			return err
+		}
+		chunkstore.Ref(hash)
+
+		b, err := file.WriteAt(data, entry.UncompressedOffset)
 		if err != nil {
 			pkg.LogError("Could not write content to file at '%s' due to: %s", msg.InitialFile.Path, err.Error())
 			return err
 		}
 		totalWrittenBytes += b
-		pkg.LogDebug("Wrote chunk of size %s to file %s", b, msg.InitialFile.Path)
 	}
 
-	// Check content checksum
-	pkg.LogDebug("Wrote %b bytes to %s", totalWrittenBytes, msg.InitialFile.Path)
+	pkg.LogDebug("Wrote %d bytes to %s, fetched %d/%d chunks from peer", totalWrittenBytes, msg.InitialFile.Path, fetchedChunks, len(toc.Entries))
 	return nil
 }
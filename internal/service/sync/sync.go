@@ -3,14 +3,20 @@ package sync
 import (
 	"JustSync/internal/config"
 	"JustSync/pkg"
+	stdsync "sync"
 
 	y "github.com/skyterra/y-crdt"
 )
 
+// SyncService owns every collaboratively-edited Y.Doc this process holds
+// (keyed by the file's path relative to the session root) and the
+// awareness state - cursor/selection - peers have reported against them.
 type SyncService struct {
 	config config.PeerConfig
 
+	mu        stdsync.Mutex
 	documents map[string]*y.Doc
+	awareness map[string]map[string][]byte // path -> peer ID -> opaque awareness payload
 }
 
 func New(cfg config.PeerConfig) SyncService {
@@ -18,9 +24,26 @@ func New(cfg config.PeerConfig) SyncService {
 		config: cfg,
 
 		documents: make(map[string]*y.Doc),
+		awareness: make(map[string]map[string][]byte),
 	}
 }
 
+var (
+	instance     *SyncService
+	instanceOnce stdsync.Once
+)
+
+// GetService returns the process-wide SyncService, built from the current
+// peer config on first use - mirroring the singleton convention
+// websocket.GetHub and the config package's own Init*Config already use.
+func GetService() *SyncService {
+	instanceOnce.Do(func() {
+		svc := New(config.GetClientConfig())
+		instance = &svc
+	})
+	return instance
+}
+
 func (s *SyncService) GetInitialSyncPayload() ([]byte, error) {
 	pkg.LogInfo("Encoding state of %d documents for initial sync.", len(s.documents))
 
@@ -32,3 +55,19 @@ func (s *SyncService) GetInitialSyncPayload() ([]byte, error) {
 
 	return payload, nil
 }
+
+// docForPath returns relativePath's Y.Doc, creating an empty one - or one
+// restored from its last persisted state, if any - the first time it's
+// asked for. Callers must hold s.mu.
+func (s *SyncService) docForPath(relativePath string) *y.Doc {
+	if doc, ok := s.documents[relativePath]; ok {
+		return doc
+	}
+
+	doc, restored := loadPersistedDocState(relativePath)
+	if !restored {
+		doc = y.NewDoc(relativePath, false, nil, nil, false)
+	}
+	s.documents[relativePath] = doc
+	return doc
+}
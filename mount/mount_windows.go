@@ -0,0 +1,14 @@
+//go:build windows
+
+package mount
+
+import "fmt"
+
+// Mount always fails on Windows: bazil.org/fuse has no Windows backend,
+// and this package doesn't pull in a WinFsp/Dokan binding to stand in for
+// it. `justsync mount` surfaces this error rather than the command simply
+// not existing, so it's clear the platform is the reason, not a missing
+// feature.
+func Mount(mountpoint string) error {
+	return fmt.Errorf("mount: FUSE mounting is not supported on Windows")
+}
@@ -1,82 +1,158 @@
+// Package pkg provides the structured logging façade used across JustSync.
+// Every subsystem gets its own *Logger via NewLogger, so log lines carry
+// which part of the system they came from and can be bumped to debug
+// independently of the rest via the JSTRACE environment variable.
 package pkg
 
 import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
 
-type LogLevel int
+type Level int
 
 const (
-	LevelDebug LogLevel = iota
+	LevelDebug Level = iota
 	LevelInfo
 	LevelWarn
 	LevelError
 )
 
 var (
-	levelNames = map[LogLevel]string{
+	levelNames = map[Level]string{
 		LevelDebug: "DEBUG",
 		LevelInfo:  "INFO",
 		LevelWarn:  "WARN",
 		LevelError: "ERROR",
 	}
-	logLevel LogLevel
-	lock     sync.Mutex
+	levelColors = map[Level]string{
+		LevelDebug: "\033[32m",
+		LevelInfo:  "\033[34m",
+		LevelWarn:  "\033[33m",
+		LevelError: "\033[31m",
+	}
+)
+
+var (
+	mu       sync.Mutex
+	level    Level
 	output   io.Writer = os.Stdout
+	colorize bool      = isTTY(os.Stdout)
 )
 
-func SetLevel(level LogLevel) {
-	lock.Lock()
-	defer lock.Unlock()
-	logLevel = level
+// SetLevel sets the default log level for subsystems not named in JSTRACE.
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
 }
 
+// SetOutput redirects every Logger's output to w, e.g. a RotatingFileWriter
+// for long-running server mode. Color is re-evaluated against the new
+// writer so redirecting to a file doesn't leave ANSI codes in it.
 func SetOutput(w io.Writer) {
-	lock.Lock()
-	defer lock.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
 	output = w
+	colorize = isTTY(w)
+}
+
+func isTTY(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// field is a single structured key/value pair attached via Logger.With.
+type field struct {
+	key string
+	val any
+}
+
+// Logger is a per-subsystem logging handle. Create one with NewLogger and
+// keep it as a package-level variable, mirroring how the rest of JustSync
+// keeps its singletons.
+type Logger struct {
+	subsystem string
+	fields    []field
 }
 
-func log(level LogLevel, color, format string, args ...any) {
-	if level < logLevel {
+// NewLogger returns a Logger for the given subsystem name, e.g.
+// pkg.NewLogger("websocket"). The subsystem name is what JSTRACE matches
+// against.
+func NewLogger(subsystem string) *Logger {
+	return &Logger{subsystem: subsystem}
+}
+
+// With returns a copy of the Logger that additionally logs key=val on every
+// subsequent call, e.g. logger.With("path", p).Info("synced").
+func (l *Logger) With(key string, val any) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	fields = append(fields, field{key, val})
+	return &Logger{subsystem: l.subsystem, fields: fields}
+}
+
+func (l *Logger) Debug(format string, args ...any) { l.log(LevelDebug, format, args...) }
+func (l *Logger) Info(format string, args ...any)  { l.log(LevelInfo, format, args...) }
+func (l *Logger) Warn(format string, args ...any)  { l.log(LevelWarn, format, args...) }
+func (l *Logger) Error(format string, args ...any) { l.log(LevelError, format, args...) }
+
+func (l *Logger) log(lvl Level, format string, args ...any) {
+	if lvl < l.effectiveLevel() {
 		return
 	}
 
-	lock.Lock()
-	defer lock.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	levelName := levelNames[level]
 	message := fmt.Sprintf(format, args...)
 
-	logEntry := fmt.Sprintf(
-		"[%s] %s [%s] \033[0m %s\n",
-		timestamp,
-		color,
-		levelName,
-		message,
-	)
+	var fields strings.Builder
+	for _, f := range l.fields {
+		fmt.Fprintf(&fields, " %s=%v", f.key, f.val)
+	}
+
+	var entry string
+	if colorize {
+		entry = fmt.Sprintf("[%s] %s[%s]\033[0m [%s] %s%s\n",
+			timestamp, levelColors[lvl], levelNames[lvl], l.subsystem, message, fields.String())
+	} else {
+		entry = fmt.Sprintf("[%s] [%s] [%s] %s%s\n",
+			timestamp, levelNames[lvl], l.subsystem, message, fields.String())
+	}
 
-	output.Write([]byte(logEntry))
+	output.Write([]byte(entry))
 }
 
-// Convenience methods
-func LogDebug(format string, args ...any) {
-	log(LevelDebug, "\033[32m", format, args...)
-}
+// effectiveLevel returns LevelDebug if JSTRACE names this subsystem (or
+// "all"), otherwise the process-wide level set via SetLevel.
+func (l *Logger) effectiveLevel() Level {
+	if traced(l.subsystem) {
+		return LevelDebug
+	}
 
-func LogInfo(format string, args ...any) {
-	log(LevelInfo, "\033[34m", format, args...)
+	mu.Lock()
+	defer mu.Unlock()
+	return level
 }
 
-func LogWarn(format string, args ...any) {
-	log(LevelWarn, "\033[33m", format, args...)
-}
+// Package-level convenience logger, used by code that hasn't been migrated
+// to its own NewLogger subsystem yet.
+var defaultLogger = NewLogger("pkg")
 
-func LogError(format string, args ...any) {
-	log(LevelError, "\033[31m", format, args...)
-}
+func LogDebug(format string, args ...any) { defaultLogger.Debug(format, args...) }
+func LogInfo(format string, args ...any)  { defaultLogger.Info(format, args...) }
+func LogWarn(format string, args ...any)  { defaultLogger.Warn(format, args...) }
+func LogError(format string, args ...any) { defaultLogger.Error(format, args...) }
@@ -0,0 +1,130 @@
+package pake
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrNonceExhausted is returned once a Session's directional nonce
+// counter would wrap, which at one message per nonce takes billions of
+// years at any plausible sync rate - this exists purely so a reused
+// nonce is a hard error rather than a silent key-recovery bug.
+var ErrNonceExhausted = errors.New("pake: nonce counter exhausted, re-run the handshake")
+
+// Session is the pair of directional ChaCha20-Poly1305 AEADs, plus the
+// pair of confirmation tags, derived from a finished SPAKE2 exchange.
+// Separate keys per direction mean a compromise of traffic flowing one
+// way doesn't help decrypt the other; the confirmation tags let the
+// server detect a wrong OTP guess immediately instead of discovering it
+// when application data fails to decrypt.
+type Session struct {
+	send cipher.AEAD
+	recv cipher.AEAD
+
+	confirmClient []byte
+	confirmServer []byte
+
+	sendNonce uint64
+	recvNonce uint64
+}
+
+// NewSession derives a Session from a finished SPAKE2 exchange. shared is
+// State.Finish's result; transcript must be built identically by both
+// parties (client share || server share) so the KDF input matches
+// regardless of which side is deriving it.
+func NewSession(role Role, shared, transcript []byte) (*Session, error) {
+	kdf := hkdf.New(sha256.New, shared, transcript, []byte("JustSync PAKE v1"))
+
+	keys := make([][]byte, 4)
+	for i := range keys {
+		keys[i] = make([]byte, chacha20poly1305.KeySize)
+		if _, err := io.ReadFull(kdf, keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	clientToServer, serverToClient, confirmClient, confirmServer := keys[0], keys[1], keys[2], keys[3]
+
+	sendKey, recvKey := clientToServer, serverToClient
+	if role == RoleServer {
+		sendKey, recvKey = serverToClient, clientToServer
+	}
+
+	send, err := chacha20poly1305.New(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recv, err := chacha20poly1305.New(recvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		send:          send,
+		recv:          recv,
+		confirmClient: confirmClient,
+		confirmServer: confirmServer,
+	}, nil
+}
+
+// ConfirmClient returns the tag the client sends to prove it derived the
+// same session as the server, and that the server checks against.
+func (s *Session) ConfirmClient() []byte {
+	return mac(s.confirmClient, "client confirms")
+}
+
+// ConfirmServer returns the tag the server would send to prove it
+// derived the same session as the client. It is not sent explicitly
+// today - a client that doesn't get this right will fail to decrypt the
+// server's first encrypted frame, which serves the same purpose - but is
+// exposed so a caller can add an explicit server->client confirmation
+// frame later without changing the key schedule.
+func (s *Session) ConfirmServer() []byte {
+	return mac(s.confirmServer, "server confirms")
+}
+
+// Seal encrypts and authenticates plaintext as the next frame in this
+// party's send direction. The nonce is a monotonic counter rather than
+// one transmitted on the wire - gorilla/websocket's underlying TCP
+// stream is already ordered and lossless, so both ends stay in sync for
+// free.
+func (s *Session) Seal(plaintext []byte) ([]byte, error) {
+	nonce, err := nonceFor(s.sendNonce)
+	if err != nil {
+		return nil, err
+	}
+	s.sendNonce++
+	return s.send.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// Open decrypts and authenticates the next frame in this party's receive
+// direction.
+func (s *Session) Open(ciphertext []byte) ([]byte, error) {
+	nonce, err := nonceFor(s.recvNonce)
+	if err != nil {
+		return nil, err
+	}
+	s.recvNonce++
+	return s.recv.Open(nil, nonce, ciphertext, nil)
+}
+
+func nonceFor(counter uint64) ([]byte, error) {
+	if counter == 1<<63 {
+		return nil, ErrNonceExhausted
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce, counter)
+	return nonce, nil
+}
+
+func mac(key []byte, label string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriter is an io.Writer alternative to os.Stdout for
+// long-running server mode. It rotates the current log file once it passes
+// MaxSizeBytes or is older than MaxAge, renaming it to "<path>.<timestamp>"
+// and starting a fresh file in its place.
+type RotatingFileWriter struct {
+	Path         string
+	MaxSizeBytes int64
+	MaxAge       time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFileWriter opens (or creates) Path and returns a writer ready
+// to use as the target of pkg.SetOutput.
+func NewRotatingFileWriter(path string, maxSizeBytes int64, maxAge time.Duration) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{Path: path, MaxSizeBytes: maxSizeBytes, MaxAge: maxAge}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	return nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingFileWriter) shouldRotate(nextWrite int) bool {
+	if w.MaxSizeBytes > 0 && w.size+int64(nextWrite) > w.MaxSizeBytes {
+		return true
+	}
+	if w.MaxAge > 0 && time.Since(w.openedAt) > w.MaxAge {
+		return true
+	}
+	return false
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	w.file.Close()
+
+	rotatedPath := fmt.Sprintf("%s.%s", w.Path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(w.Path, rotatedPath); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
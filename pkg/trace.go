@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// tracedSubsystems is populated once from JSTRACE, a comma-separated list of
+// subsystem names to force to debug level regardless of SetLevel, e.g.
+// JSTRACE=snapshot,websocket or JSTRACE=all.
+var (
+	tracedOnce sync.Once
+	tracedAll  bool
+	tracedSet  map[string]bool
+)
+
+func loadTraced() {
+	tracedSet = make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv("JSTRACE"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			tracedAll = true
+			continue
+		}
+		tracedSet[name] = true
+	}
+}
+
+func traced(subsystem string) bool {
+	tracedOnce.Do(loadTraced)
+	return tracedAll || tracedSet[subsystem]
+}
@@ -0,0 +1,38 @@
+package service
+
+import "sync"
+
+// ackMu and highestSeq track the highest sequence number this peer has
+// received from its host with no gaps before it, mirroring the
+// session_id.go package-singleton pattern: there's exactly one host
+// connection per process, so a package-level tracker is simpler than
+// threading an ack tracker object through every call site that reads a
+// WebsocketMessage.
+var (
+	ackMu      sync.Mutex
+	highestSeq uint64
+)
+
+// TrackIncomingSeq advances the locally tracked highest contiguous
+// sequence number if seq is its immediate successor. A gap (seq skips
+// ahead, e.g. from a message lost in transit) is deliberately not
+// recorded here - there's no retransmit-request message yet, so a gap is
+// only closed by reconnecting and letting the host's OutboundQueue.Replay
+// resend everything after the last ack.
+func TrackIncomingSeq(seq uint64) {
+	ackMu.Lock()
+	defer ackMu.Unlock()
+
+	if seq == highestSeq+1 {
+		highestSeq = seq
+	}
+}
+
+// LastAckedSeq returns the highest contiguous sequence number received so
+// far, for the ack loop to report back to the host and for a reconnect to
+// put in its ResumeRequest.
+func LastAckedSeq() uint64 {
+	ackMu.Lock()
+	defer ackMu.Unlock()
+	return highestSeq
+}
@@ -0,0 +1,185 @@
+// Package blockcache is a shared, process-wide LRU over chunk content,
+// keyed by checksum. ApplyFileDelta used to hold every chunk of a file's
+// old version resident in a throwaway map on every single delta; this
+// gives it (and ProcessNewFileSync) one bounded cache to route chunk
+// fetches through instead, and a natural seam to plug in a remote
+// (peer-to-peer) chunk fetch later without touching either caller again.
+package blockcache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"JustSync/snapshot"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// DefaultMaxFileBytes bounds how much of a single file's chunks WarmFile
+// will admit in one call, so reconstructing one huge file can't by itself
+// push every other cached chunk out.
+const DefaultMaxFileBytes = 100 * 1024 * 1024 // 100MiB
+
+// DefaultMaxTotalBytes bounds how much chunk content the cache holds
+// resident across every file, process-wide.
+const DefaultMaxTotalBytes = 1024 * 1024 * 1024 // 1GiB
+
+// FetchFunc fetches a chunk's content from whatever backs the cache on a
+// miss - today always chunkstore.Get, later potentially a peer-to-peer
+// chunk request.
+type FetchFunc func(checksum []byte) ([]byte, error)
+
+// block is one cache entry. Its own mutex, rather than the Cache's, is
+// held across a fetch, so concurrent Get calls for the same chunk
+// serialize on that one fetch (single-flight per block) instead of
+// blocking every other chunk in the cache.
+type block struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+// Cache is a process-wide LRU over chunk content, bounded by total bytes
+// resident, with Prometheus-style hit/miss/eviction counters.
+type Cache struct {
+	maxFileBytes  int64
+	maxTotalBytes int64
+
+	mu         sync.Mutex
+	blocks     *lru.Cache[string, *block]
+	totalBytes int64
+
+	hits, misses, evictions atomic.Int64
+}
+
+// New returns a Cache admitting at most maxFileBytes per WarmFile call and
+// maxTotalBytes overall. A non-positive value falls back to this package's
+// default for that bound.
+func New(maxFileBytes, maxTotalBytes int64) *Cache {
+	if maxFileBytes <= 0 {
+		maxFileBytes = DefaultMaxFileBytes
+	}
+	if maxTotalBytes <= 0 {
+		maxTotalBytes = DefaultMaxTotalBytes
+	}
+
+	// The LRU's own count-based capacity is set arbitrarily high - Cache
+	// enforces the real, byte-based budget itself via admit, evicting the
+	// globally oldest block whenever that budget is exceeded.
+	blocks, _ := lru.New[string, *block](1 << 20)
+
+	return &Cache{
+		maxFileBytes:  maxFileBytes,
+		maxTotalBytes: maxTotalBytes,
+		blocks:        blocks,
+	}
+}
+
+// Get returns checksum's content, preferring the cache and calling fetch
+// at most once on a miss even when multiple goroutines ask for the same
+// chunk concurrently.
+func (c *Cache) Get(checksum []byte, fetch FetchFunc) ([]byte, error) {
+	b := c.entry(checksum)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.data != nil {
+		c.hits.Add(1)
+		return b.data, nil
+	}
+
+	c.misses.Add(1)
+	data, err := fetch(checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	b.data = data
+	c.admit(int64(len(data)))
+	return data, nil
+}
+
+// Put admits data for checksum directly, for a caller that already has the
+// content in hand (e.g. a chunk it just wrote) rather than needing a fetch.
+func (c *Cache) Put(checksum, data []byte) {
+	b := c.entry(checksum)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.data != nil {
+		return
+	}
+
+	b.data = data
+	c.admit(int64(len(data)))
+}
+
+// WarmFile seeds the cache with a file's chunks up front - e.g. the old
+// version's chunks, before reconstructing a delta against it - capped at
+// maxFileBytes so one large file can't by itself blow the cache's global
+// budget. Chunks beyond the cap are simply left for a later Get to fetch
+// and cache normally if something actually asks for them. chunks carries
+// only hash/offset/size - the persisted snapshot never holds content
+// itself - so fetch supplies the bytes to admit.
+func (c *Cache) WarmFile(chunks []*snapshot.InitialSyncChunk, fetch FetchFunc) {
+	var budget int64
+	for _, chunk := range chunks {
+		if budget+chunk.Size > c.maxFileBytes {
+			continue
+		}
+		budget += chunk.Size
+		if _, err := c.Get(chunk.Checksum, fetch); err != nil {
+			continue
+		}
+	}
+}
+
+func (c *Cache) entry(checksum []byte) *block {
+	key := string(checksum)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if b, ok := c.blocks.Get(key); ok {
+		return b
+	}
+	b := &block{}
+	c.blocks.Add(key, b)
+	return b
+}
+
+// admit accounts size against the total budget, evicting the globally
+// oldest block until back under it. Callers must not hold the evicted
+// block's own mu.
+func (c *Cache) admit(size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.totalBytes += size
+	for c.totalBytes > c.maxTotalBytes && c.blocks.Len() > 1 {
+		_, evicted, ok := c.blocks.RemoveOldest()
+		if !ok {
+			break
+		}
+		if evicted.data != nil {
+			c.totalBytes -= int64(len(evicted.data))
+		}
+		c.evictions.Add(1)
+	}
+}
+
+// Stats is a point-in-time snapshot of the cache's Prometheus-style
+// counters.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// Stats returns the cache's current hit/miss/eviction counts.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
@@ -0,0 +1,147 @@
+// Package blockdiff produces the minimal AddedChunks/MovedChunks/
+// RemovedChunkHashes triple needed to turn an old InitialSyncFile into a new
+// version of a file, using an rsync-style two-hash scheme: a fast rolling
+// checksum narrows down candidates, and the existing chunk checksum (see
+// utils.GetHasher) confirms an actual match before it is trusted.
+//
+// This complements, rather than replaces, the content-defined chunking in
+// utils.ChunkFileContentDefined: that function decides where chunk
+// boundaries go when a file is first snapshotted, while this package
+// reconciles an old snapshot against an arbitrary new version of the file
+// using fixed-size windows, the way rsync's signature/delta pair does.
+package blockdiff
+
+import (
+	"JustSync/snapshot"
+	"JustSync/utils"
+	"bytes"
+)
+
+// BlockSize is the fixed window size the rolling hash slides over the new
+// file content. It is independent of the CDC chunk sizes in utils/os.go.
+const BlockSize = 8 * 1024
+
+// Result is the set of changes needed to turn an old file into a new one.
+type Result struct {
+	AddedChunks        []*snapshot.AddedChunk
+	MovedChunks        []*snapshot.MovedChunk
+	RemovedChunkHashes [][]byte
+}
+
+// weakHash is a rolling checksum in the spirit of rsync's/Adler-32's weak
+// sum: s1 accumulates the window's bytes, s2 accumulates their weighted
+// sum, and both update in O(1) as the window slides one byte at a time.
+type weakHash struct {
+	s1, s2     uint32
+	windowSize uint32
+}
+
+func newWeakHash(window []byte) *weakHash {
+	w := &weakHash{windowSize: uint32(len(window))}
+	for i, b := range window {
+		w.s1 += uint32(b)
+		w.s2 += (w.windowSize - uint32(i)) * uint32(b)
+	}
+	return w
+}
+
+func (w *weakHash) roll(out, in byte) {
+	w.s1 += uint32(in) - uint32(out)
+	w.s2 += w.s1 - w.windowSize*uint32(out)
+}
+
+func (w *weakHash) sum() uint32 {
+	return w.s2<<16 | (w.s1 & 0xffff)
+}
+
+type oldBlock struct {
+	checksum []byte
+	content  []byte
+}
+
+// Compute diffs newContent against old, sliding a BlockSize window over
+// newContent one byte at a time, matching it against old's chunks by weak
+// hash and confirming hits with
+// the strong chunk checksum. Unmatched runs become AddedChunks, matched
+// regions become MovedChunks, and old chunks never hit become
+// RemovedChunkHashes.
+func Compute(old *snapshot.InitialSyncFile, newContent []byte) (*Result, error) {
+	hasher := utils.GetHasher()
+
+	byWeak := make(map[uint32][]oldBlock, len(old.Chunks))
+	for _, c := range old.Chunks {
+		byWeak[newWeakHash(c.Content).sum()] = append(byWeak[newWeakHash(c.Content).sum()], oldBlock{
+			checksum: c.Checksum,
+			content:  c.Content,
+		})
+	}
+
+	matched := make(map[string]bool, len(old.Chunks))
+	res := &Result{}
+
+	n := len(newContent)
+	literalStart := 0
+	i := 0
+	var wh *weakHash
+
+	for i+BlockSize <= n {
+		window := newContent[i : i+BlockSize]
+		if wh == nil {
+			wh = newWeakHash(window)
+		} else {
+			wh.roll(newContent[i-1], window[len(window)-1])
+		}
+
+		candidates, hit := byWeak[wh.sum()]
+		if !hit {
+			i++
+			continue
+		}
+
+		strong := hasher(window)
+		var match *oldBlock
+		for idx := range candidates {
+			if bytes.Equal(candidates[idx].checksum, strong) {
+				match = &candidates[idx]
+				break
+			}
+		}
+		if match == nil {
+			i++
+			continue
+		}
+
+		if i > literalStart {
+			res.AddedChunks = append(res.AddedChunks, literal(hasher, newContent[literalStart:i], literalStart))
+		}
+		res.MovedChunks = append(res.MovedChunks, &snapshot.MovedChunk{
+			Checksum:  match.checksum,
+			NewOffset: int64(i),
+		})
+		matched[string(match.checksum)] = true
+
+		i += BlockSize
+		literalStart = i
+		wh = nil
+	}
+
+	if literalStart < n {
+		res.AddedChunks = append(res.AddedChunks, literal(hasher, newContent[literalStart:], literalStart))
+	}
+
+	for _, c := range old.Chunks {
+		if !matched[string(c.Checksum)] {
+			res.RemovedChunkHashes = append(res.RemovedChunkHashes, c.Checksum)
+		}
+	}
+
+	return res, nil
+}
+
+func literal(hasher func([]byte) []byte, content []byte, offset int) *snapshot.AddedChunk {
+	return &snapshot.AddedChunk{
+		Checksum:  hasher(content),
+		Content:   content,
+		NewOffset: int64(offset),
+	}
+}
@@ -0,0 +1,295 @@
+// Package chunkstore is a content-addressable, refcounted store for chunk
+// bytes, shared across every file and sync session instead of each
+// snapshot embedding its own copy. Chunks are persisted under a two-level
+// fan-out by their blake3 hash, mirroring the shared-cache + GC model used
+// by BuildKit's fscache: writers Ref what they use, readers Prune whatever
+// no live snapshot references any more.
+package chunkstore
+
+import (
+	"JustSync/pkg"
+	"JustSync/snapshot"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Root is where chunk content and the refcount ledger are persisted,
+// alongside the project snapshot itself.
+const Root = "snapshot/chunks"
+
+const refcountsFile = "refcounts.json"
+
+// ledger is the on-disk shape of refcountsFile. unreffedAt records when a
+// hash's refcount last dropped to zero, so Prune can give a just-released
+// chunk a grace period instead of deleting it the instant a delta momentarily
+// drops its last reference before re-Ref'ing it a moment later.
+type ledger struct {
+	Refs       map[string]int       `json:"refs"`
+	UnreffedAt map[string]time.Time `json:"unreffedAt"`
+}
+
+var (
+	mu         sync.Mutex
+	refcounts  map[string]int
+	unreffedAt map[string]time.Time
+	loaded     bool
+)
+
+var log = pkg.NewLogger("chunkstore")
+
+// Put writes data for hash to disk if it is not already stored. It does
+// not affect hash's refcount - callers that intend to keep the chunk
+// alive must also call Ref.
+func Put(hash, data []byte) error {
+	path := blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Get reads back the content stored for hash.
+func Get(hash []byte) ([]byte, error) {
+	return os.ReadFile(blobPath(hash))
+}
+
+// Has reports whether hash is already present in the store.
+func Has(hash []byte) bool {
+	_, err := os.Stat(blobPath(hash))
+	return err == nil
+}
+
+// Ref increments hash's reference count, persisting the ledger. A hash
+// re-Ref'd after dropping to zero loses its unreffedAt grace-period entry,
+// since it's live again.
+func Ref(hash []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	ensureLoaded()
+
+	key := hex.EncodeToString(hash)
+	refcounts[key]++
+	delete(unreffedAt, key)
+	persist()
+}
+
+// Unref decrements hash's reference count, persisting the ledger. It does
+// not delete the underlying blob; dropping to zero just starts the
+// hash's Prune grace period, so a chunk momentarily unreferenced mid-delta
+// isn't evicted out from under a reconstruction that's about to Ref it
+// again.
+func Unref(hash []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	ensureLoaded()
+
+	key := hex.EncodeToString(hash)
+	if refcounts[key] > 0 {
+		refcounts[key]--
+	}
+	if refcounts[key] <= 0 {
+		delete(refcounts, key)
+		unreffedAt[key] = time.Now()
+	}
+	persist()
+}
+
+// Prune deletes every chunk under Root that the ledger shows as
+// unreferenced for at least minAge, using the refcounts Ref/Unref have
+// already been maintaining. It returns the number of blobs removed.
+//
+// Prune is the routine, cheap sweep; GC is the slower, authoritative one
+// that rebuilds the ledger itself from a project snapshot first.
+func Prune(minAge time.Duration) (int, error) {
+	mu.Lock()
+	ensureLoaded()
+	dead := make(map[string]bool)
+	for key, since := range unreffedAt {
+		if refcounts[key] > 0 {
+			continue
+		}
+		if time.Since(since) >= minAge {
+			dead[key] = true
+		}
+	}
+	mu.Unlock()
+
+	removed, err := removeBlobs(dead)
+	if err != nil {
+		return removed, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for key := range dead {
+		delete(refcounts, key)
+		delete(unreffedAt, key)
+	}
+	persist()
+
+	log.Info("Pruned %d chunks unreferenced for at least %s", removed, minAge)
+	return removed, nil
+}
+
+// GC recomputes the live set from scratch by walking the current project
+// snapshot (rather than trusting the Ref/Unref ledger, which a crash
+// between writing a snapshot and its matching Ref call could have left
+// stale), rebuilds the ledger to match, and unlinks every blob the
+// snapshot no longer references - regardless of how recently it was
+// released.
+//
+// TODO: once multiple concurrently-loaded project snapshots are
+// supported (JustSync/internal/config's Folders list groundwork),
+// this should union the live chunk set across all of them rather than
+// just the single global snapshot.GetSnapshot().
+func GC() (int, error) {
+	live := make(map[string]bool)
+	counts := make(map[string]int)
+	if snap := snapshot.GetSnapshot(); snap != nil {
+		for _, file := range snap.Files {
+			for _, chunk := range file.Chunks {
+				key := hex.EncodeToString(chunk.Checksum)
+				live[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	dead := make(map[string]bool)
+	err := filepath.WalkDir(Root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Base(path) == refcountsFile {
+			return nil
+		}
+		if hash, ok := hashFromBlobPath(path); ok && !live[hash] {
+			dead[hash] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := removeBlobs(dead)
+	if err != nil {
+		return removed, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	ensureLoaded()
+	refcounts = counts
+	unreffedAt = make(map[string]time.Time)
+	persist()
+
+	log.Info("GC rebuilt the chunk ledger from the live snapshot and pruned %d chunks", removed)
+	return removed, nil
+}
+
+// removeBlobs unlinks every blob under Root whose hex hash is in dead.
+func removeBlobs(dead map[string]bool) (int, error) {
+	if len(dead) == 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.WalkDir(Root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Base(path) == refcountsFile {
+			return nil
+		}
+
+		hash, ok := hashFromBlobPath(path)
+		if !ok || !dead[hash] {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			log.Warn("Could not prune chunk %s: %s", hash, err.Error())
+			return nil
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+func blobPath(hash []byte) string {
+	name := hex.EncodeToString(hash)
+	if len(name) < 4 {
+		return filepath.Join(Root, name)
+	}
+	return filepath.Join(Root, name[:2], name[2:4], name)
+}
+
+// hashFromBlobPath recovers the hex hash a blob was stored under; blobPath
+// always names the file after the full hash regardless of fan-out depth.
+func hashFromBlobPath(path string) (string, bool) {
+	name := filepath.Base(path)
+	if _, err := hex.DecodeString(name); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// ensureLoaded reads the refcount ledger from disk once per process.
+// Callers must hold mu.
+func ensureLoaded() {
+	if loaded {
+		return
+	}
+	loaded = true
+	refcounts = make(map[string]int)
+	unreffedAt = make(map[string]time.Time)
+
+	data, err := os.ReadFile(filepath.Join(Root, refcountsFile))
+	if err != nil {
+		return
+	}
+	var l ledger
+	if err := json.Unmarshal(data, &l); err != nil {
+		log.Warn("Could not parse refcount ledger, starting fresh: %s", err.Error())
+		return
+	}
+	if l.Refs != nil {
+		refcounts = l.Refs
+	}
+	if l.UnreffedAt != nil {
+		unreffedAt = l.UnreffedAt
+	}
+}
+
+// persist writes the refcount ledger to disk. Callers must hold mu.
+func persist() {
+	if err := os.MkdirAll(Root, 0755); err != nil {
+		log.Warn("Could not create chunk store root: %s", err.Error())
+		return
+	}
+
+	data, err := json.Marshal(ledger{Refs: refcounts, UnreffedAt: unreffedAt})
+	if err != nil {
+		log.Warn("Could not marshal refcount ledger: %s", err.Error())
+		return
+	}
+	if err := os.WriteFile(filepath.Join(Root, refcountsFile), data, 0644); err != nil {
+		log.Warn("Could not persist refcount ledger: %s", err.Error())
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	syncsvc "JustSync/internal/service/sync"
+	"JustSync/snapshot"
+	"fmt"
+	"os"
+)
+
+// SyncCollaborativeFile builds an update message for a locally-edited,
+// collaborative-mode file, ready to hand to websocket.SendToHost. It is
+// SyncFile's counterpart for the path ErrCollaborativeFile names: instead
+// of diffing chunks, it feeds the file's new content into the process-wide
+// sync.SyncService's Y.Doc for path (see internal/service/sync) and wraps
+// whatever update comes out as a YUpdate.
+//
+// SyncCollaborativeFile returns ErrNoChange, and a nil message, when the
+// file's content matches the Y.Doc's current text already - e.g. an echo
+// of an update this peer just applied from somewhere else.
+func SyncCollaborativeFile(path string) (*snapshot.WebsocketMessage, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file data: %w", err)
+	}
+
+	update, err := syncsvc.GetService().ApplyLocalEdit(path, content)
+	if err != nil {
+		return nil, err
+	}
+	if update == nil {
+		return nil, ErrNoChange
+	}
+
+	msg := syncsvc.BuildYUpdateMessage(path, update)
+	return &msg, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// EventType names one kind of sync lifecycle event Events can publish and
+// be subscribed to.
+type EventType string
+
+const (
+	// EventSyncStarted fires when a host begins pushing a project sync to
+	// a peer, or a peer requests one manually via api.RequestSync.
+	EventSyncStarted EventType = "sync_started"
+	// EventFileDeltaApplied fires once a received FileDelta has been
+	// fully reconstructed and verified.
+	EventFileDeltaApplied EventType = "file_delta_applied"
+	// EventInitialFileReceived fires once a received InitialFile has
+	// been fully written and verified.
+	EventInitialFileReceived EventType = "initial_file_received"
+	// EventSyncFinished fires when a project sync (or a single
+	// api.RequestSync call) completes sending.
+	EventSyncFinished EventType = "sync_finished"
+	// EventPeerRegistered fires when a peer completes its handshake and
+	// is registered with a Hub.
+	EventPeerRegistered EventType = "peer_registered"
+	// EventPeerUnregistered fires when a registered peer disconnects.
+	EventPeerUnregistered EventType = "peer_unregistered"
+	// EventHandshakeFailed fires when a peer's connection attempt fails
+	// the auth handshake, before it is ever registered.
+	EventHandshakeFailed EventType = "handshake_failed"
+	// EventConflictDetected fires when ApplyFileDelta finds that an
+	// incoming delta's BaseChecksum/VectorClock show it raced a local
+	// edit, rather than simply replaying history this peer already knows.
+	EventConflictDetected EventType = "conflict_detected"
+)
+
+// Event is one structured sync lifecycle event. Not every field applies to
+// every EventType - see the EventType constants above for which fields
+// each one populates.
+type Event struct {
+	Type EventType
+	// At is when the event was published.
+	At time.Time
+	// Path is the file a sync event concerns, empty for peer/handshake
+	// events.
+	Path string
+	// Bytes is the size of the marshalled manifest message for the
+	// EventSyncFinished that websocket's DoFullProjectSync publishes;
+	// every other publisher, FileDelta/InitialFile events included,
+	// leaves it 0.
+	Bytes int64
+	// Duration is how long the operation the event reports on took.
+	Duration time.Duration
+	// PeerId identifies the peer a peer-lifecycle event concerns, or the
+	// peer a sync was sent to/received from.
+	PeerId string
+	// Err carries the failure reason for EventHandshakeFailed, empty
+	// otherwise.
+	Err string
+}
+
+// eventBus is a typed, non-blocking pub/sub bus: a slow or absent
+// subscriber can never hold up the sync path that publishes to it.
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventType][]chan Event
+	all         []chan Event
+}
+
+// subscriberBuffer is how many unconsumed events a subscriber channel
+// holds before Publish starts dropping events for it, rather than
+// blocking the caller.
+const subscriberBuffer = 32
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[EventType][]chan Event)}
+}
+
+// Events is the process-wide sync lifecycle bus. Subscribe to it from an
+// API handler or metrics exporter; publish to it from wherever a sync
+// lifecycle transition actually happens.
+var Events = newEventBus()
+
+// Subscribe returns a channel that receives every future event of type t.
+// The channel is never closed; a subscriber that's done listening should
+// simply stop reading from it and let it be garbage collected.
+func (b *eventBus) Subscribe(t EventType) <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[t] = append(b.subscribers[t], ch)
+	return ch
+}
+
+// SubscribeAll returns a channel that receives every future event
+// regardless of type, the feed api.HandleEvents fans out over SSE.
+func (b *eventBus) SubscribeAll() <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, ch)
+	return ch
+}
+
+// Publish fans e out to every subscriber of e.Type and every SubscribeAll
+// subscriber, stamping At if the caller left it zero. A subscriber whose
+// buffer is full is skipped for this event rather than blocking the
+// publisher - a slow UI falls behind, it doesn't stall a sync.
+func (b *eventBus) Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subscribers[e.Type] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	for _, ch := range b.all {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// NewPeerID generates a short random identifier for a newly connected
+// peer, used by websocket.ServeWs to give each Client a stable id for
+// PeerRegistered/PeerUnregistered events - it has no id available any
+// earlier than that, since the session/resume ID isn't
+// read off the wire until after the handshake registers the connection.
+func NewPeerID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unseeded-peer"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"JustSync/filter"
+	"JustSync/service/chunkstore"
+	"JustSync/snapshot"
+	"JustSync/utils"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BuildProjectManifest walks the host's project root exactly like
+// PrepareInitiateProjectSync, but - instead of pushing every file's chunk
+// content up front - it records only each file's ordered chunk hashes. A
+// single ProjectManifest message lets a peer kick off a sync regardless of
+// project size, then pull back just the chunks its own chunkstore doesn't
+// already hold via ChunkRequest, instead of the host pushing every
+// InitialFile up front and blowing a bounded send channel on anything
+// nontrivial.
+func BuildProjectManifest() (*snapshot.ProjectManifest, error) {
+	app := utils.GetHostConfig().Application
+	projectRoot := app.Path
+
+	match, err := filter.New(projectRoot, app.Exclude, app.Include)
+	if err != nil {
+		return nil, fmt.Errorf("could not load %s: %w", filter.IgnoreFileName, err)
+	}
+
+	manifest := &snapshot.ProjectManifest{}
+
+	if err := filepath.WalkDir(projectRoot, func(absolutePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			utils.LogError("Error traversing project to build manifest: %s", err.Error())
+			return err
+		}
+
+		relativePath, relErr := filepath.Rel(projectRoot, absolutePath)
+		if relErr != nil {
+			relativePath = absolutePath
+		}
+
+		if d.IsDir() {
+			if relativePath != "." && match.Match(relativePath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match.Match(relativePath, false) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 && !app.FollowSymlinks {
+			utils.LogDebug("Skipping symlink %s", relativePath)
+			return nil
+		}
+		if info, err := d.Info(); err == nil && app.MaxFileSizeBytes > 0 && info.Size() > app.MaxFileSizeBytes {
+			utils.LogWarn("Skipping %s: %d bytes exceeds the configured max file size of %d", relativePath, info.Size(), app.MaxFileSizeBytes)
+			return nil
+		}
+
+		file, err := os.Open(absolutePath)
+		if err != nil {
+			utils.LogError("Error reading file at %s: %s", absolutePath, err.Error())
+			return err
+		}
+		defer file.Close()
+
+		chunks, err := utils.ChunkFileContentDefined(file)
+		if err != nil {
+			utils.LogError("Could not chunk content of file %s due to error: %s", absolutePath, err.Error())
+			return err
+		}
+
+		hashes := make([][]byte, len(chunks))
+		var size int64
+		for i, c := range chunks {
+			hashes[i] = c.Hash
+			size += c.Size
+		}
+
+		manifest.Files = append(manifest.Files, &snapshot.ManifestFile{
+			Path:        []byte(relativePath),
+			Size:        size,
+			ChunkHashes: hashes,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// AnswerChunkRequest re-chunks the file req.Path names under the host
+// project root and returns exactly the chunks req.ChunkHashes asked for -
+// everything else in the file is assumed already held by the peer's
+// chunkstore, per BuildProjectManifest's full hash list for it.
+func AnswerChunkRequest(req *snapshot.ChunkRequest) (*snapshot.WebsocketMessage, error) {
+	app := utils.GetHostConfig().Application
+	absPath := filepath.Join(app.Path, string(req.Path))
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s to answer chunk request: %w", req.Path, err)
+	}
+	defer file.Close()
+
+	chunks, err := utils.ChunkFileContentDefined(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not chunk %s to answer chunk request: %w", req.Path, err)
+	}
+
+	want := make(map[string]bool, len(req.ChunkHashes))
+	for _, h := range req.ChunkHashes {
+		want[string(h)] = true
+	}
+
+	var out []*snapshot.InitialSyncChunk
+	for _, c := range chunks {
+		if !want[string(c.Hash)] {
+			continue
+		}
+
+		content, err := chunkstore.Get(c.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %x of %s missing from chunk store right after chunking it: %w", c.Hash, req.Path, err)
+		}
+		wireContent, codec := snapshot.CompressChunkContent(content)
+		out = append(out, &snapshot.InitialSyncChunk{
+			Checksum: c.Hash,
+			Content:  wireContent,
+			Codec:    codec,
+			Offset:   c.Offset,
+			Size:     c.Size,
+		})
+	}
+
+	return &snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_ChunkResponse{
+			ChunkResponse: &snapshot.ChunkResponse{Path: req.Path, Chunks: out},
+		},
+	}, nil
+}
+
+// ProcessManifest persists manifest onto the current transfer session's
+// journal (see service/session_id.go) and returns the ChunkRequest
+// messages this peer needs to send back, one per file that has at least
+// one chunk its chunkstore doesn't already hold. A file that's already
+// fully present - the common case on a resume - is assembled immediately,
+// with no round trip at all.
+func ProcessManifest(manifest *snapshot.ProjectManifest) ([]*snapshot.WebsocketMessage, error) {
+	journal := CurrentJournal()
+	journal.Manifest = manifest
+	if err := snapshot.WriteJournal(journal); err != nil {
+		return nil, fmt.Errorf("could not persist project manifest: %w", err)
+	}
+
+	var requests []*snapshot.WebsocketMessage
+	for _, file := range manifest.Files {
+		var missing [][]byte
+		for _, hash := range file.ChunkHashes {
+			if !chunkstore.Has(hash) {
+				missing = append(missing, hash)
+			}
+		}
+
+		if len(missing) == 0 {
+			if err := assembleManifestFile(file); err != nil {
+				utils.LogError("Could not assemble %s from chunk store: %s", file.Path, err.Error())
+			}
+			continue
+		}
+
+		requests = append(requests, &snapshot.WebsocketMessage{
+			Payload: &snapshot.WebsocketMessage_ChunkRequest{
+				ChunkRequest: &snapshot.ChunkRequest{Path: file.Path, ChunkHashes: missing},
+			},
+		})
+	}
+	return requests, nil
+}
+
+// ApplyChunkResponse stores every chunk resp carries into the chunkstore
+// and assembles the file it belongs to: ProcessManifest only ever
+// requests a file's missing chunks, so everything else it needs was
+// already present by the time this runs.
+func ApplyChunkResponse(resp *snapshot.ChunkResponse) error {
+	journal := CurrentJournal()
+	relPath := string(resp.Path)
+
+	for _, chunk := range resp.Chunks {
+		content, err := snapshot.DecompressChunkContent(chunk.Content, chunk.Codec)
+		if err != nil {
+			return fmt.Errorf("could not decompress chunk %x of %s: %w", chunk.Checksum, relPath, err)
+		}
+		if err := chunkstore.Put(chunk.Checksum, content); err != nil {
+			return fmt.Errorf("could not persist chunk %x of %s: %w", chunk.Checksum, relPath, err)
+		}
+		chunkstore.Ref(chunk.Checksum)
+		snapshot.MarkChunkState(journal, relPath, chunk.Checksum, snapshot.ChunkState_VERIFIED)
+	}
+
+	file := manifestFile(journal.Manifest, relPath)
+	if file == nil {
+		return fmt.Errorf("received a chunk response for %s, which isn't in the manifest", relPath)
+	}
+	return assembleManifestFile(file)
+}
+
+// assembleManifestFile writes file's content to disk by pulling every
+// chunk listed in file.ChunkHashes, in order, straight out of the
+// chunkstore, the same way ProcessNewFileSync reassembles a withheld
+// chunk. Every hash is guaranteed present by the time this is called,
+// either because ProcessManifest found it already there or because
+// ApplyChunkResponse just stored it.
+func assembleManifestFile(file *snapshot.ManifestFile) error {
+	cfg := utils.GetClientConfig()
+	relPath := string(file.Path)
+	if err := enforceReceivePolicy(relPath, file.Size); err != nil {
+		utils.LogError(err.Error())
+		return err
+	}
+
+	path := filepath.Join(cfg.Session.Path, cfg.Session.Name, relPath)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create directory structure %s: %w", dir, err)
+	}
+
+	tmpPath := path + ".partial"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not create temp file %s: %w", tmpPath, err)
+	}
+
+	var offset int64
+	for _, hash := range file.ChunkHashes {
+		content, err := sharedChunkCache.Get(hash, chunkstore.Get)
+		if err != nil {
+			tmpFile.Close()
+			return fmt.Errorf("chunk %x of %s missing from chunk store: %w", hash, relPath, err)
+		}
+		if _, err := tmpFile.WriteAt(content, offset); err != nil {
+			tmpFile.Close()
+			return fmt.Errorf("could not write content to %s: %w", relPath, err)
+		}
+		offset += int64(len(content))
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("could not finalize temp file %s: %w", tmpPath, err)
+	}
+
+	if len(file.Checksum) > 0 {
+		if err := verifyFileChecksum(tmpPath, file.Checksum); err != nil {
+			return fmt.Errorf("checksum mismatch reconstructing %s, leaving %s in place for a future resume: %w", relPath, tmpPath, err)
+		}
+		MarkExpectedWrite(path, file.Checksum)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("could not finalize %s: %w", path, err)
+	}
+
+	utils.LogInfo("Assembled %s from manifest (%d bytes)", relPath, offset)
+	return nil
+}
+
+func manifestFile(manifest *snapshot.ProjectManifest, relPath string) *snapshot.ManifestFile {
+	if manifest == nil {
+		return nil
+	}
+	for _, f := range manifest.Files {
+		if string(f.Path) == relPath {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"JustSync/service/chunkstore"
+	"JustSync/snapshot"
+	"JustSync/utils"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNoChange is returned by SyncFile when the file's content checksum
+// hasn't moved since the last snapshot, so there is nothing to send.
+var ErrNoChange = errors.New("service: file unchanged since last sync")
+
+// ErrCollaborativeFile is returned by SyncFile when the file's folder is
+// configured for collaborative editing - such a file converges through
+// the y-sync CRDT protocol instead (see internal/service/sync), so the
+// CDC delta path built here has nothing to do with it.
+var ErrCollaborativeFile = errors.New("service: file is synced via the collaborative CRDT path, not CDC deltas")
+
+// expectedWriteTTL bounds how long a MarkExpectedWrite entry stays valid.
+// A write that never shows up as an fsnotify event (e.g. ApplyFileDelta
+// erroring out before the rename) would otherwise leave a stale
+// suppression entry sitting around forever.
+const expectedWriteTTL = 5 * time.Second
+
+type expectedWrite struct {
+	checksum string
+	at       time.Time
+}
+
+var (
+	expectedWritesMu sync.Mutex
+	expectedWrites   = make(map[string]expectedWrite)
+)
+
+// MarkExpectedWrite records that path is about to be overwritten on disk
+// with content matching checksum because of an incoming sync, so
+// service/watcher can recognize the resulting fsnotify event as an echo of
+// this write rather than a local edit and skip re-syncing it.
+func MarkExpectedWrite(path string, checksum []byte) {
+	expectedWritesMu.Lock()
+	defer expectedWritesMu.Unlock()
+	expectedWrites[path] = expectedWrite{checksum: hex.EncodeToString(checksum), at: time.Now()}
+}
+
+// ConsumeExpectedWrite reports whether path's most recent write was marked
+// via MarkExpectedWrite with a matching checksum, removing the entry
+// either way so a later genuine local edit of the same path isn't
+// mistakenly suppressed too.
+func ConsumeExpectedWrite(path string, checksum []byte) bool {
+	expectedWritesMu.Lock()
+	defer expectedWritesMu.Unlock()
+
+	entry, ok := expectedWrites[path]
+	delete(expectedWrites, path)
+	if !ok || time.Since(entry.at) > expectedWriteTTL {
+		return false
+	}
+	return entry.checksum == hex.EncodeToString(checksum)
+}
+
+// isCollaborativeFolder reports whether the configured folder routes
+// through the CRDT sync path, mirroring versioningPolicy's convention of
+// consulting just the first configured folder until per-folder path
+// matching is wired up.
+func isCollaborativeFolder() bool {
+	folders := utils.GetClientConfig().Folders
+	if len(folders) == 0 {
+		return false
+	}
+	return folders[0].CollabMode
+}
+
+// SyncFile builds the chunk-level delta for the file at path against the
+// current project snapshot, updates the snapshot to match, and returns the
+// FileDelta message ready to hand to websocket.SendToHost. It is the
+// chunk-diffing pipeline shared by the manual /send-sync endpoint
+// (api.RequestSync) and the filesystem watcher (service/watcher) - both
+// just hand it a changed path and send what comes back.
+//
+// SyncFile returns ErrNoChange, and a nil message, when the file's content
+// checksum already matches the snapshot, and ErrCollaborativeFile when the
+// configured folder routes through the CRDT path instead.
+func SyncFile(path string) (*snapshot.WebsocketMessage, error) {
+	if isCollaborativeFolder() {
+		return nil, ErrCollaborativeFile
+	}
+
+	// Open the file for chunking.
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file data: %w", err)
+	}
+	defer file.Close()
+
+	// Immediately chunk the file to get the definitive list of new chunks.
+	// ChunkFileContentDefined already persisted each chunk's content into
+	// chunkstore, keyed by hash - it only returns hash/offset/size, so the
+	// snapshot entry built below never has to carry content itself.
+	newChunks, err := utils.ChunkFileContentDefined(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not chunk file %s: %w", path, err)
+	}
+
+	// Reconstruct the file content FROM THE CHUNKS, fetching each one back
+	// out of chunkstore, to get the definitive content.
+	var finalSize int64
+	for _, chunk := range newChunks {
+		if chunkEnd := chunk.Offset + chunk.Size; chunkEnd > finalSize {
+			finalSize = chunkEnd
+		}
+	}
+	reconstructedContent := make([]byte, finalSize)
+	for _, chunk := range newChunks {
+		content, err := chunkstore.Get(chunk.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %x of %s missing from chunk store right after chunking it: %w", chunk.Hash, path, err)
+		}
+		copy(reconstructedContent[chunk.Offset:], content)
+	}
+
+	// Calculate the checksum on this reconstructed content. This is the authoritative hash.
+	hasher := utils.GetHasher()
+	hash := hasher(reconstructedContent)
+
+	// Now, check if the file has actually changed.
+	snap := snapshot.GetSnapshot()
+	if oldFile, ok := snap.Files[path]; ok {
+		if bytes.Equal(hash, oldFile.Checksum) {
+			utils.LogInfo("Sync request rejected, no change in file detected.")
+			return nil, ErrNoChange
+		}
+	}
+
+	// Prepare new snapshot object. Only hash/offset/size are persisted here
+	// - content always comes back from chunkstore on demand (see
+	// reconstructedContent above and rollingDeltaInstructions below), so the
+	// snapshot itself can't grow unbounded with every edit.
+	newSnapshotChunks := make([]*snapshot.InitialSyncChunk, len(newChunks))
+	for i, chunk := range newChunks {
+		newSnapshotChunks[i] = &snapshot.InitialSyncChunk{
+			Checksum: chunk.Hash,
+			Offset:   chunk.Offset,
+			Size:     chunk.Size,
+		}
+	}
+
+	newSnapshot := snapshot.GetSnapshot()
+	// Ensure the file entry exists in the snapshot before trying to access its chunks
+	if _, ok := newSnapshot.Files[path]; !ok {
+		newSnapshot.Files[path] = &snapshot.InitialSyncFile{}
+	}
+	newSnapshot.Files[path].Checksum = hash
+	newSnapshot.Files[path].Chunks = newSnapshotChunks // Replace old chunks with the new definitive ones
+
+	// Bump this peer's own entry in the file's vector clock so a receiver
+	// can tell this edit apart from one that merely replays history it
+	// already knows (see vectorClockDominates), even when BaseChecksum
+	// below doesn't literally chain - e.g. because this peer last synced
+	// through a host that's since applied someone else's edit too.
+	var baseChecksum []byte
+	var baseClock map[string]uint64
+	if oldFile, ok := snap.Files[path]; ok {
+		baseChecksum = oldFile.Checksum
+		baseClock = oldFile.VectorClock
+	}
+	newVectorClock := bumpVectorClock(baseClock, localPeerID())
+	newSnapshot.Files[path].VectorClock = newVectorClock
+
+	// Prepare file delta calculation
+	oldChunkMap := make(map[string]*snapshot.InitialSyncChunk) // hash -> chunk
+	newChunkMap := make(map[string]*snapshot.Chunk)            // hash -> chunk
+	// Use the old snapshot for comparison
+	if oldFile, ok := snap.Files[path]; ok {
+		for _, chunk := range oldFile.Chunks {
+			oldChunkMap[string(chunk.Checksum)] = chunk
+		}
+	}
+	for _, chunk := range newChunks {
+		newChunkMap[string(chunk.Hash)] = chunk
+	}
+
+	snapshot.WriteSnapshot(newSnapshot)
+
+	msg := snapshot.FileDelta{
+		Path:               path,
+		Checksum:           hash, // Use the authoritative hash - this delta's NewChecksum
+		BaseChecksum:       baseChecksum,
+		OriginPeerId:       localPeerID(),
+		VectorClock:        newVectorClock,
+		AddedChunks:        []*snapshot.AddedChunk{},
+		MovedChunks:        []*snapshot.MovedChunk{},
+		RemovedChunkHashes: [][]byte{},
+	}
+
+	for newChunkHash, newChunk := range newChunkMap {
+		if oldChunk, exists := oldChunkMap[newChunkHash]; !exists {
+			// Chunk added. Its content never made it into newSnapshotChunks
+			// above, so fetch it back out of chunkstore for the wire copy.
+			content, err := chunkstore.Get(newChunk.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("chunk %x of %s missing from chunk store right after chunking it: %w", newChunk.Hash, path, err)
+			}
+			wireContent, codec := snapshot.CompressChunkContent(content)
+			msg.AddedChunks = append(msg.AddedChunks, &snapshot.AddedChunk{
+				Checksum:  newChunk.Hash,
+				Content:   wireContent,
+				Codec:     codec,
+				NewOffset: newChunk.Offset,
+			})
+		} else if oldChunk.Offset != newChunk.Offset {
+			// Chunk moved
+			msg.MovedChunks = append(msg.MovedChunks, &snapshot.MovedChunk{
+				Checksum:  newChunk.Hash,
+				NewOffset: newChunk.Offset,
+			})
+		}
+	}
+
+	for oldChunkHash := range oldChunkMap {
+		if _, exists := newChunkMap[oldChunkHash]; !exists {
+			msg.RemovedChunkHashes = append(msg.RemovedChunkHashes, []byte(oldChunkHash))
+		}
+	}
+
+	// Rolling-delta encoding is additive, not a replacement for the CDC
+	// chunk diff above: ApplyFileDelta only switches to it when
+	// RollingBlockSize is set, so a peer running an older build (or with
+	// the flag off) still reconstructs correctly from AddedChunks/
+	// MovedChunks/RemovedChunkHashes alone.
+	if oldFile, ok := snap.Files[path]; ok && utils.GetClientConfig().Session.RollingDeltaEncoding {
+		instructions, err := rollingDeltaInstructions(oldFile, reconstructedContent)
+		if err != nil {
+			utils.LogWarn("Could not build rolling delta for %s, falling back to chunk delta: %s", path, err.Error())
+		} else {
+			msg.RollingBlockSize = utils.RollingBlockSize
+			msg.RollingInstructions = instructions
+		}
+	}
+
+	return &snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_FileDelta{
+			FileDelta: &msg,
+		},
+	}, nil
+}
+
+// rollingDeltaInstructions reconstructs oldFile's content from its chunks
+// (the basis version, reassembled the same way SyncFile just reassembled
+// newChunks into reconstructedContent above, fetching each chunk's content
+// back out of sharedChunkCache/chunkstore since the persisted snapshot only
+// carries hash/offset/size) and diffs newContent against a rolling-hash
+// signature of it, so a receiver that still has the basis version can
+// reconstruct newContent from a handful of literal byte ranges instead of
+// needing every chunk AddedChunks touched re-sent.
+func rollingDeltaInstructions(oldFile *snapshot.InitialSyncFile, newContent []byte) ([]*snapshot.DeltaInstruction, error) {
+	var basisSize int64
+	for _, chunk := range oldFile.Chunks {
+		if end := chunk.Offset + chunk.Size; end > basisSize {
+			basisSize = end
+		}
+	}
+	basis := make([]byte, basisSize)
+	for _, chunk := range oldFile.Chunks {
+		content, err := sharedChunkCache.Get(chunk.Checksum, chunkstore.Get)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %x of basis version missing from chunk store: %w", chunk.Checksum, err)
+		}
+		copy(basis[chunk.Offset:], content)
+	}
+
+	sig, err := utils.ComputeSignature(bytes.NewReader(basis))
+	if err != nil {
+		return nil, fmt.Errorf("could not compute rolling signature of basis version: %w", err)
+	}
+
+	return utils.ComputeDeltaInstructions(newContent, sig), nil
+}
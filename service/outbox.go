@@ -0,0 +1,151 @@
+package service
+
+import (
+	"JustSync/pkg"
+	"JustSync/snapshot"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// OutboundRoot is where each peer's unacked outgoing messages are
+// persisted, alongside the project snapshot and chunk store, so a host
+// restart mid-sync doesn't lose anything a peer hasn't acked yet.
+const OutboundRoot = "snapshot/outbound"
+
+var outboxLog = pkg.NewLogger("outbox")
+
+// OutboundQueue stamps every outgoing WebsocketMessage a peer is sent with
+// a monotonically increasing sequence number and keeps every unacked one
+// around in a ring, so a dropped connection or a full send channel no
+// longer means restarting that peer's entire project sync from scratch -
+// Replay picks the peer back up from whatever it last acked. It is keyed
+// by that peer's session ID and persisted to disk, so the same holds
+// across a host restart.
+type OutboundQueue struct {
+	mu      sync.Mutex
+	key     string
+	seq     uint64
+	acked   uint64
+	pending map[uint64]*snapshot.WebsocketMessage
+}
+
+// NewOutboundQueue loads key's persisted queue, if one exists, or starts a
+// fresh one.
+func NewOutboundQueue(key string) *OutboundQueue {
+	q := &OutboundQueue{key: key, pending: make(map[uint64]*snapshot.WebsocketMessage)}
+	q.load()
+	return q
+}
+
+// Stamp assigns msg the next sequence number, records it as unacked, and
+// persists the queue before returning, so a crash right after can't lose
+// it silently.
+func (q *OutboundQueue) Stamp(msg *snapshot.WebsocketMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	msg.Seq = q.seq
+	q.pending[q.seq] = msg
+	q.persist()
+}
+
+// Ack discards every pending message up to and including
+// highestContiguous, the peer having confirmed it holds everything
+// through that point.
+func (q *OutboundQueue) Ack(highestContiguous uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if highestContiguous <= q.acked {
+		return
+	}
+	for seq := q.acked + 1; seq <= highestContiguous; seq++ {
+		delete(q.pending, seq)
+	}
+	q.acked = highestContiguous
+	q.persist()
+}
+
+// Replay returns every still-pending message with a sequence greater than
+// fromSeq, in order, for a reconnecting peer to be resent instead of
+// retriggering a full project sync.
+func (q *OutboundQueue) Replay(fromSeq uint64) []*snapshot.WebsocketMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var out []*snapshot.WebsocketMessage
+	for seq := fromSeq + 1; seq <= q.seq; seq++ {
+		if msg, ok := q.pending[seq]; ok {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// onDiskQueue is the persisted shape of an OutboundQueue; pending messages
+// are kept proto-marshaled since snapshot.WebsocketMessage isn't itself
+// JSON-serializable.
+type onDiskQueue struct {
+	Seq     uint64            `json:"seq"`
+	Acked   uint64            `json:"acked"`
+	Pending map[uint64][]byte `json:"pending"`
+}
+
+func (q *OutboundQueue) persist() {
+	d := onDiskQueue{Seq: q.seq, Acked: q.acked, Pending: make(map[uint64][]byte, len(q.pending))}
+	for seq, msg := range q.pending {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			outboxLog.Warn("Could not marshal pending message %d for %s: %s", seq, q.key, err.Error())
+			continue
+		}
+		d.Pending[seq] = data
+	}
+
+	if err := os.MkdirAll(OutboundRoot, 0755); err != nil {
+		outboxLog.Warn("Could not create %s: %s", OutboundRoot, err.Error())
+		return
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		outboxLog.Warn("Could not marshal outbound queue for %s: %s", q.key, err.Error())
+		return
+	}
+	if err := os.WriteFile(q.path(), data, 0644); err != nil {
+		outboxLog.Warn("Could not persist outbound queue for %s: %s", q.key, err.Error())
+	}
+}
+
+func (q *OutboundQueue) load() {
+	data, err := os.ReadFile(q.path())
+	if err != nil {
+		return
+	}
+
+	var d onDiskQueue
+	if err := json.Unmarshal(data, &d); err != nil {
+		outboxLog.Warn("Could not parse persisted outbound queue for %s: %s", q.key, err.Error())
+		return
+	}
+
+	q.seq = d.Seq
+	q.acked = d.Acked
+	q.pending = make(map[uint64]*snapshot.WebsocketMessage, len(d.Pending))
+	for seq, raw := range d.Pending {
+		var msg snapshot.WebsocketMessage
+		if err := proto.Unmarshal(raw, &msg); err != nil {
+			outboxLog.Warn("Could not parse persisted message %d for %s: %s", seq, q.key, err.Error())
+			continue
+		}
+		q.pending[seq] = &msg
+	}
+}
+
+func (q *OutboundQueue) path() string {
+	return filepath.Join(OutboundRoot, q.key+".json")
+}
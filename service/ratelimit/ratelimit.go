@@ -0,0 +1,180 @@
+// Package ratelimit metes out bytes per connection, in each direction
+// independently, and keeps a process-wide account of what every peer is
+// actually sending and receiving - so one peer pulling a large
+// DoFullProjectSync can no longer starve everyone else's writePump, and an
+// operator has something to look at besides logs to spot a runaway
+// client.
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bucket is a token-bucket limiter metered in bytes. A Bucket built with a
+// non-positive rate is unbounded: Wait returns immediately and never
+// blocks, so leaving RateLimitBytesPerSec unset costs nothing.
+type Bucket struct {
+	ratePerSec float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket returns a Bucket that admits bytesPerSec bytes/sec on average,
+// bursting up to one second's worth at a time. bytesPerSec <= 0 means
+// unbounded.
+func NewBucket(bytesPerSec int64) *Bucket {
+	if bytesPerSec <= 0 {
+		return &Bucket{}
+	}
+	return &Bucket{
+		ratePerSec: float64(bytesPerSec),
+		tokens:     float64(bytesPerSec),
+		last:       time.Now(),
+	}
+}
+
+// Wait blocks until n bytes' worth of tokens are available and consumes
+// them, sleeping out any deficit rather than dropping the caller - the
+// back-pressure that keeps a slow peer's writePump from burying everyone
+// else's send channel instead of just disconnecting it.
+func (b *Bucket) Wait(n int) {
+	if b == nil || b.ratePerSec <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.ratePerSec
+	if b.tokens > b.ratePerSec {
+		b.tokens = b.ratePerSec
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / b.ratePerSec * float64(time.Second)))
+	}
+}
+
+// peerStats is one connection's raw bandwidth account: bytes moved in each
+// direction, and how many messages of each payload type crossed the wire
+// which way.
+type peerStats struct {
+	mu                  sync.Mutex
+	bytesIn, bytesOut   int64
+	countsIn, countsOut map[string]int64
+}
+
+// Registry is a process-wide table of per-peer bandwidth accounts, keyed
+// by the same connection id a Peer's service.Events carry (see
+// service.NewPeerID). Register/Unregister bracket a connection's
+// lifetime, mirroring Hub's register/unregister channels, so a peer that
+// disconnects stops showing up in Snapshot rather than leaking forever.
+type Registry struct {
+	mu    sync.Mutex
+	peers map[string]*peerStats
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{peers: make(map[string]*peerStats)}
+}
+
+// Register starts tracking bandwidth for peerID, replacing any stale
+// entry left behind by a previous connection that reused the same id.
+func (r *Registry) Register(peerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers[peerID] = &peerStats{countsIn: make(map[string]int64), countsOut: make(map[string]int64)}
+}
+
+// Unregister stops tracking peerID, dropping its accumulated counters.
+func (r *Registry) Unregister(peerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.peers, peerID)
+}
+
+// RecordIn accounts n bytes of an incoming message of the given payload
+// type against peerID, a no-op if peerID was never Register'd (or was
+// already Unregister'd).
+func (r *Registry) RecordIn(peerID, payloadType string, n int) {
+	r.record(peerID, payloadType, n, true)
+}
+
+// RecordOut accounts n bytes of an outgoing message of the given payload
+// type against peerID, a no-op if peerID was never Register'd (or was
+// already Unregister'd).
+func (r *Registry) RecordOut(peerID, payloadType string, n int) {
+	r.record(peerID, payloadType, n, false)
+}
+
+func (r *Registry) record(peerID, payloadType string, n int, inbound bool) {
+	r.mu.Lock()
+	stats, ok := r.peers[peerID]
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if inbound {
+		stats.bytesIn += int64(n)
+		stats.countsIn[payloadType]++
+	} else {
+		stats.bytesOut += int64(n)
+		stats.countsOut[payloadType]++
+	}
+}
+
+// PeerSnapshot is a point-in-time copy of one peer's bandwidth account,
+// safe to read after Snapshot returns.
+type PeerSnapshot struct {
+	PeerID              string
+	BytesIn, BytesOut   int64
+	CountsIn, CountsOut map[string]int64
+}
+
+// Snapshot returns every currently-registered peer's bandwidth account,
+// sorted by PeerID for deterministic /admin/bandwidth output.
+func (r *Registry) Snapshot() []PeerSnapshot {
+	r.mu.Lock()
+	ids := make([]string, 0, len(r.peers))
+	statsByID := make(map[string]*peerStats, len(r.peers))
+	for id, stats := range r.peers {
+		ids = append(ids, id)
+		statsByID[id] = stats
+	}
+	r.mu.Unlock()
+
+	sort.Strings(ids)
+	out := make([]PeerSnapshot, len(ids))
+	for i, id := range ids {
+		stats := statsByID[id]
+		stats.mu.Lock()
+		snap := PeerSnapshot{
+			PeerID:    id,
+			BytesIn:   stats.bytesIn,
+			BytesOut:  stats.bytesOut,
+			CountsIn:  make(map[string]int64, len(stats.countsIn)),
+			CountsOut: make(map[string]int64, len(stats.countsOut)),
+		}
+		for t, n := range stats.countsIn {
+			snap.CountsIn[t] = n
+		}
+		for t, n := range stats.countsOut {
+			snap.CountsOut[t] = n
+		}
+		stats.mu.Unlock()
+		out[i] = snap
+	}
+	return out
+}
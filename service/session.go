@@ -23,8 +23,24 @@ func HandleCreateSnapshot(path string) error {
 	return nil
 }
 
+// Conn is the subset of *websocket.Conn that
+// HandleReceiveAndProcessIncomingMessages needs, satisfied by a direct
+// connection or by a JustSync/websocket.EncryptedConn established through
+// a relay pairing - so a relay-paired session is consumed identically to
+// a direct one.
+type Conn interface {
+	ReadMessage() (int, []byte, error)
+}
+
 // CLIENT: Main event loop
-func HandleReceiveAndProcessIncomingMessages(conn *websocket.Conn) {
+//
+// send transmits a message back to the host - needed for the manifest-
+// first sync path below, where a ChunkRequest has to go back out over the
+// same logical connection conn reads from. It's passed in rather than
+// read off conn itself because this package can't import JustSync/websocket
+// (which itself imports this package); callers wire it to
+// websocket.SendToHost.
+func HandleReceiveAndProcessIncomingMessages(conn Conn, send func(*snapshot.WebsocketMessage) error) {
 	for {
 		msgType, rawMsg, err := conn.ReadMessage()
 		if err != nil {
@@ -45,6 +61,7 @@ func HandleReceiveAndProcessIncomingMessages(conn *websocket.Conn) {
 			utils.LogError("Failed to unmarshal protobuf message received from websocket: %s", err.Error())
 			continue
 		}
+		TrackIncomingSeq(msg.Seq)
 
 		switch t := msg.Payload.(type) {
 		case *snapshot.WebsocketMessage_StartSync:
@@ -56,8 +73,14 @@ func HandleReceiveAndProcessIncomingMessages(conn *websocket.Conn) {
 		case *snapshot.WebsocketMessage_FileDelta:
 			utils.LogInfo("Received file: %s", t.FileDelta.Path)
 			start := time.Now()
-			if err := ApplyFileDelta(*t); err != nil {
+			if err := ApplyFileDelta(*t, send); err != nil {
 				utils.LogError("Could not process file sync of file '%s' due to %s", t.FileDelta.Path, err.Error())
+			} else {
+				Events.Publish(Event{
+					Type:     EventFileDeltaApplied,
+					Path:     t.FileDelta.Path,
+					Duration: time.Since(start),
+				})
 			}
 			elapsed := time.Since(start)
 			utils.LogInfo("Successfully processed %s in %s", t.FileDelta.Path, elapsed)
@@ -66,12 +89,46 @@ func HandleReceiveAndProcessIncomingMessages(conn *websocket.Conn) {
 			start := time.Now()
 			if err := ProcessNewFileSync(*t); err != nil {
 				utils.LogError("Could not process file sync of file '%s' due to %s", t.InitialFile.Path, err.Error())
+			} else {
+				Events.Publish(Event{
+					Type:     EventInitialFileReceived,
+					Path:     string(t.InitialFile.Path),
+					Duration: time.Since(start),
+				})
 			}
 			elapsed := time.Since(start)
 			utils.LogInfo("Successfully processed %s in %s", t.InitialFile.Path, elapsed)
 		case *snapshot.WebsocketMessage_EndSync:
 			utils.LogInfo("Finishing sync up!")
 			HandleCreateSnapshot(utils.GetClientConfig().Session.Path)
+		case *snapshot.WebsocketMessage_ProjectManifest:
+			utils.LogInfo("Received project manifest listing %d files", len(t.ProjectManifest.Files))
+			requests, err := ProcessManifest(t.ProjectManifest)
+			if err != nil {
+				utils.LogError("Could not process project manifest: %s", err.Error())
+				continue
+			}
+			for _, req := range requests {
+				if err := send(req); err != nil {
+					utils.LogError("Could not send chunk request: %s", err.Error())
+				}
+			}
+		case *snapshot.WebsocketMessage_ChunkResponse:
+			utils.LogInfo("Received %d chunks for %s", len(t.ChunkResponse.Chunks), t.ChunkResponse.Path)
+			if err := ApplyChunkResponse(t.ChunkResponse); err != nil {
+				utils.LogError("Could not apply chunk response for '%s': %s", t.ChunkResponse.Path, err.Error())
+			}
+		case *snapshot.WebsocketMessage_Conflict:
+			// The other side of a delta we sent detected that it raced a
+			// local edit there (see handleSyncConflict) - there's no UI
+			// layer here to surface this to a user through, so just make
+			// sure it shows up in logs/events on this side too.
+			utils.LogWarn("Peer reported a sync conflict for %s", t.Conflict.Path)
+			Events.Publish(Event{
+				Type:   EventConflictDetected,
+				Path:   t.Conflict.Path,
+				PeerId: t.Conflict.OriginPeerId,
+			})
 		default:
 			utils.LogError("Recieved message of unexpected type: %T", t)
 		}
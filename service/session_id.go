@@ -0,0 +1,63 @@
+package service
+
+import (
+	"JustSync/snapshot"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+var (
+	sessionMu sync.Mutex
+	sessionID string
+	resuming  bool
+	journal   *snapshot.TransferJournal
+)
+
+// BeginSession establishes the transfer session this peer's incoming sync
+// is tracked under, loading its journal. Passing a non-empty resumeID
+// picks up an interrupted session instead of starting a fresh one - the
+// returned hashes are every chunk that session's journal already marked
+// snapshot.ChunkState_VERIFIED, for a ResumeRequest to report to the host.
+func BeginSession(resumeID string) (id string, haveChunks [][]byte) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	if resumeID != "" {
+		sessionID = resumeID
+		resuming = true
+	} else {
+		sessionID = newSessionID()
+		resuming = false
+	}
+
+	journal = snapshot.LoadJournal(sessionID)
+	return sessionID, snapshot.HaveChunks(journal)
+}
+
+// CurrentJournal returns the transfer journal for the session established
+// by the most recent BeginSession call.
+func CurrentJournal() *snapshot.TransferJournal {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return journal
+}
+
+// Resuming reports whether BeginSession was called with an existing
+// session ID, so PrepareReceiveProjectSync knows an already-populated
+// destination folder is expected rather than a conflict.
+func Resuming() bool {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return resuming
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand failing means the system's entropy source is broken;
+		// fall back to a fixed ID rather than leaving the session untracked.
+		return "unseeded-session"
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"JustSync/filter"
+	"JustSync/service/blockcache"
+	"JustSync/service/chunkstore"
+	"JustSync/service/versioning"
 	"JustSync/snapshot"
 	"JustSync/utils"
 	"bytes"
@@ -11,15 +15,88 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 )
 
-func PrepareInitiateProjectSync() ([]snapshot.WebsocketMessage, error) {
-	projectRoot := utils.GetHostConfig().Application.Path
+// sharedChunkCache is the process-wide LRU every chunk fetch in this file
+// routes through, so reconstructing a delta no longer means loading a
+// whole file's worth of chunk content into a throwaway map only to
+// discard it once the delta's applied.
+var sharedChunkCache = blockcache.New(blockcache.DefaultMaxFileBytes, blockcache.DefaultMaxTotalBytes)
+
+// ChunkCacheStats reports the shared chunk block cache's current
+// hit/miss/eviction counters, for api.HandleBlockCacheMetrics to expose.
+func ChunkCacheStats() blockcache.Stats {
+	return sharedChunkCache.Stats()
+}
+
+// ErrFilteredByPolicy is returned by ProcessNewFileSync/ApplyFileDelta when
+// an incoming file or delta fails this peer's own filter/max-file-size
+// policy, so a misbehaving or malicious host can't push something the
+// local config forbids just because it chose to send it.
+var ErrFilteredByPolicy = errors.New("service: file rejected by local filter policy")
+
+// enforceReceivePolicy checks relPath and size (the reconstructed file's
+// size, not the wire size) against this peer's own .justsyncignore/
+// exclude/include/MaxFileSizeBytes config - the same policy
+// PrepareInitiateProjectSync applies on the sending side - independently of
+// whatever the other end decided to send.
+func enforceReceivePolicy(relPath string, size int64) error {
+	cfg := utils.GetClientConfig()
+	root := filepath.Join(cfg.Session.Path, cfg.Session.Name)
+
+	match, err := filter.New(root, cfg.Session.Exclude, cfg.Session.Include)
+	if err != nil {
+		return fmt.Errorf("could not load %s: %w", filter.IgnoreFileName, err)
+	}
+	if match.Match(relPath, false) {
+		return fmt.Errorf("%w: %s is excluded", ErrFilteredByPolicy, relPath)
+	}
+	if cfg.Session.MaxFileSizeBytes > 0 && size > cfg.Session.MaxFileSizeBytes {
+		return fmt.Errorf("%w: %s is %d bytes, exceeding the configured max of %d", ErrFilteredByPolicy, relPath, size, cfg.Session.MaxFileSizeBytes)
+	}
+	return nil
+}
+
+// relativeToSessionRoot converts path - an absolute path as FileDelta
+// messages carry it - into one relative to the session root, for matching
+// against a filter.Matcher. A path filepath.Rel can't relate to the
+// session root (e.g. a relative path against an absolute root) is
+// returned unchanged, same fallback watcher.ignored and utils.ProcessDir
+// use for the same filepath.Rel call; an absolute path outside the root
+// comes back as a "../"-prefixed relative path.
+func relativeToSessionRoot(path string) string {
+	cfg := utils.GetClientConfig()
+	root := filepath.Join(cfg.Session.Path, cfg.Session.Name)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// PrepareInitiateProjectSync builds the message sequence for a full project
+// sync. have is the set of chunk hashes (as returned by a peer's
+// ResumeRequest) the receiving peer already holds - a chunk whose hash is
+// in have is sent with its Content stripped, since the peer can pull it
+// back out of its own chunkstore instead of receiving it over the wire
+// again. A fresh join passes a nil/empty have, so every chunk is sent in
+// full; resuming one is just this same path with a non-empty have.
+func PrepareInitiateProjectSync(have map[string]bool) ([]snapshot.WebsocketMessage, error) {
+	app := utils.GetHostConfig().Application
+	projectRoot := app.Path
 	var messages []snapshot.WebsocketMessage
 	// Append start sync msg
 	startSyncMsg := snapshot.WebsocketMessage_StartSync{}
 	messages = append(messages, snapshot.WebsocketMessage{Payload: &startSyncMsg})
 
+	match, err := filter.New(projectRoot, app.Exclude, app.Include)
+	if err != nil {
+		utils.LogError("Could not load %s: %s", filter.IgnoreFileName, err.Error())
+		return messages, err
+	}
+
 	// Append sync msg's for each file
 	if err := filepath.WalkDir(projectRoot, func(absolutePath string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -27,8 +104,27 @@ func PrepareInitiateProjectSync() ([]snapshot.WebsocketMessage, error) {
 			return err
 		}
 
-		// Skip directories
+		relativePath, relErr := filepath.Rel(projectRoot, absolutePath)
+		if relErr != nil {
+			relativePath = absolutePath
+		}
+
 		if d.IsDir() {
+			if relativePath != "." && match.Match(relativePath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if match.Match(relativePath, false) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 && !app.FollowSymlinks {
+			utils.LogDebug("Skipping symlink %s", relativePath)
+			return nil
+		}
+		if info, err := d.Info(); err == nil && app.MaxFileSizeBytes > 0 && info.Size() > app.MaxFileSizeBytes {
+			utils.LogWarn("Skipping %s: %d bytes exceeds the configured max file size of %d", relativePath, info.Size(), app.MaxFileSizeBytes)
 			return nil
 		}
 
@@ -39,12 +135,6 @@ func PrepareInitiateProjectSync() ([]snapshot.WebsocketMessage, error) {
 		}
 		defer file.Close()
 
-		relativePath, err := filepath.Rel(projectRoot, absolutePath)
-		if err != nil {
-			utils.LogError("Could not shrink the absolute path to be relative due to: %s", err.Error())
-			return err
-		}
-
 		fileChunks, err := utils.ChunkFileContentDefined(file)
 		if err != nil {
 			utils.LogError("Could not chunk content of file %s due to error: %s", absolutePath, err.Error())
@@ -57,9 +147,15 @@ func PrepareInitiateProjectSync() ([]snapshot.WebsocketMessage, error) {
 			return err
 		}
 
+		wireChunks, err := toInitialSyncChunks(fileChunks)
+		if err != nil {
+			utils.LogError("Could not prepare chunks of %s for sync: %s", absolutePath, err.Error())
+			return err
+		}
+
 		fileSync := &snapshot.InitialSyncFile{
 			Checksum: utils.GetHasher()(fileContent),
-			Chunks:   fileChunks,
+			Chunks:   withheldChunkContent(wireChunks, have),
 		}
 		syncMsg := snapshot.WebsocketMessage{
 			Payload: &snapshot.WebsocketMessage_InitialFile{
@@ -86,12 +182,73 @@ func PrepareInitiateProjectSync() ([]snapshot.WebsocketMessage, error) {
 	return messages, nil
 }
 
+// toInitialSyncChunks converts chunks - the hash/offset/size-only result of
+// utils.ChunkFileContentDefined - into wire-ready InitialSyncChunks by
+// pulling each one's content back out of chunkstore, the same way
+// CreateSnapshotOfFile does for the legacy full-snapshot format. The
+// result is only ever a transient wire copy; callers must not persist it
+// into a ProjectSnapshot entry as-is (see withheldChunkContent).
+func toInitialSyncChunks(chunks []*snapshot.Chunk) ([]*snapshot.InitialSyncChunk, error) {
+	out := make([]*snapshot.InitialSyncChunk, len(chunks))
+	for i, chunk := range chunks {
+		content, err := chunkstore.Get(chunk.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %x missing from chunk store right after chunking it: %w", chunk.Hash, err)
+		}
+		out[i] = &snapshot.InitialSyncChunk{
+			Checksum: chunk.Hash,
+			Content:  content,
+			Offset:   chunk.Offset,
+			Size:     chunk.Size,
+		}
+	}
+	return out, nil
+}
+
+// withheldChunkContent builds the wire copy of chunks: an entry whose
+// checksum is in have has its Content cleared, since the peer can pull it
+// back out of its own chunkstore instead of receiving it over the wire
+// again; everything else is zstd-compressed per snapshot.CompressChunkContent,
+// tagged with the codec it ends up using. Either way the returned chunks are
+// fresh copies, so the canonical chunks this file's snapshot entry holds are
+// never mutated.
+func withheldChunkContent(chunks []*snapshot.InitialSyncChunk, have map[string]bool) []*snapshot.InitialSyncChunk {
+	out := make([]*snapshot.InitialSyncChunk, len(chunks))
+	for i, chunk := range chunks {
+		if have[string(chunk.Checksum)] {
+			out[i] = &snapshot.InitialSyncChunk{
+				Checksum: chunk.Checksum,
+				Offset:   chunk.Offset,
+				Size:     chunk.Size,
+			}
+			continue
+		}
+
+		content, codec := snapshot.CompressChunkContent(chunk.Content)
+		out[i] = &snapshot.InitialSyncChunk{
+			Checksum: chunk.Checksum,
+			Content:  content,
+			Codec:    codec,
+			Offset:   chunk.Offset,
+			Size:     chunk.Size,
+		}
+	}
+	return out
+}
+
+// PrepareReceiveProjectSync ensures the destination folder exists before an
+// incoming sync starts, unless Resuming reports we're picking an
+// interrupted session back up - in which case the folder is expected to
+// already be there, partially populated.
 func PrepareReceiveProjectSync() error {
 	cfg := utils.GetClientConfig()
 	path := cfg.Session.Path + cfg.Session.Name
 
 	_, err := os.Stat(cfg.Session.Path)
 	if err == nil {
+		if Resuming() {
+			return nil
+		}
 		utils.LogError("Folder with name %s already existing at %s", cfg.Session.Name, cfg.Session.Path)
 		return err
 	}
@@ -108,43 +265,136 @@ func PrepareReceiveProjectSync() error {
 	return nil
 }
 
-// ProcessNewFileSync builds up a file at a given path and fills it with the desired content
+// ProcessNewFileSync writes an incoming file's chunks to a sibling
+// "<path>.partial" file, tracking each chunk's progress in the current
+// transfer session's journal (see service/session_id.go), and only
+// os.Renames the temp file into place once every chunk has reached
+// snapshot.ChunkState_VERIFIED. A chunk that arrives with no Content was
+// withheld by the host because a prior ResumeRequest reported this peer
+// already has it (see PrepareInitiateProjectSync) - it's read back out of
+// the shared chunkstore instead. If the process dies partway through, a
+// later `justsync peer join --resume` re-sends this same message and
+// ProcessNewFileSync picks the partial file back up, since WriteAt to the
+// same offsets is idempotent.
 func ProcessNewFileSync(msg snapshot.WebsocketMessage_InitialFile) error {
-	// Build the path for the new file
 	cfg := utils.GetClientConfig()
-	path := filepath.Join(cfg.Session.Path, cfg.Session.Name, string(msg.InitialFile.Path))
+	relPath := string(msg.InitialFile.Path)
+
+	var fileSize int64
+	for _, chunk := range msg.InitialFile.File.Chunks {
+		if end := chunk.Offset + chunk.Size; end > fileSize {
+			fileSize = end
+		}
+	}
+	if err := enforceReceivePolicy(relPath, fileSize); err != nil {
+		utils.LogError(err.Error())
+		return err
+	}
+
+	path := filepath.Join(cfg.Session.Path, cfg.Session.Name, relPath)
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		utils.LogError("Unable to create directory structure '%s' due to: %s", dir, err.Error())
 		return err
 	}
 
-	// Create the actual file
-	file, err := os.Create(path)
+	// A prior run may have already finished and renamed this file into
+	// place before the connection dropped - nothing left to do.
+	if existing, err := os.Open(path); err == nil {
+		checksum, hashErr := utils.HashReader(existing)
+		existing.Close()
+		if hashErr == nil && bytes.Equal(checksum, msg.InitialFile.File.Checksum) {
+			utils.LogDebug("%s already fully synced, skipping", relPath)
+			return nil
+		}
+	}
+
+	tmpPath := path + ".partial"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		utils.LogError("Could not create file %s due to error: %s", path, err.Error())
+		utils.LogError("Could not create temp file %s due to error: %s", tmpPath, err.Error())
 		return err
 	}
 
-	// Fill the file with the actual content
+	journal := CurrentJournal()
 	totalWrittenBytes := 0
 	for _, chunk := range msg.InitialFile.File.Chunks {
-		b, err := file.WriteAt(chunk.Content, chunk.Offset)
+		content := chunk.Content
+		if len(content) == 0 {
+			content, err = sharedChunkCache.Get(chunk.Checksum, chunkstore.Get)
+			if err != nil {
+				tmpFile.Close()
+				utils.LogError("Host withheld chunk %x of '%s' expecting this peer already had it, but it wasn't in the local chunk store: %s", chunk.Checksum, relPath, err.Error())
+				return err
+			}
+		} else {
+			content, err = snapshot.DecompressChunkContent(content, chunk.Codec)
+			if err != nil {
+				tmpFile.Close()
+				utils.LogError("Could not decompress chunk %x of '%s': %s", chunk.Checksum, relPath, err.Error())
+				return err
+			}
+		}
+
+		snapshot.MarkChunkState(journal, relPath, chunk.Checksum, snapshot.ChunkState_INFLIGHT)
+
+		b, err := tmpFile.WriteAt(content, chunk.Offset)
 		if err != nil {
-			utils.LogError("Could not write content to file at '%s' due to: %s", msg.InitialFile.Path, err.Error())
+			tmpFile.Close()
+			utils.LogError("Could not write content to file at '%s' due to: %s", relPath, err.Error())
 			return err
 		}
 		totalWrittenBytes += b
-		utils.LogDebug("Wrote chunk of size %s to file %s", b, msg.InitialFile.Path)
+
+		if err := chunkstore.Put(chunk.Checksum, content); err != nil {
+			utils.LogError("Could not persist chunk of %s to chunk store: %s", relPath, err.Error())
+		}
+		chunkstore.Ref(chunk.Checksum)
+
+		snapshot.MarkChunkState(journal, relPath, chunk.Checksum, snapshot.ChunkState_WRITTEN)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		utils.LogError("Could not finalize temp file %s: %s", tmpPath, err.Error())
+		return err
+	}
+
+	if err := verifyFileChecksum(tmpPath, msg.InitialFile.File.Checksum); err != nil {
+		utils.LogError("Checksum mismatch reconstructing %s, leaving %s in place for a future resume: %s", relPath, tmpPath, err.Error())
+		return err
 	}
 
-	// Check content checksum
-	utils.LogInfo("Wrote %b bytes to %s", totalWrittenBytes, msg.InitialFile.Path)
+	for _, chunk := range msg.InitialFile.File.Chunks {
+		snapshot.MarkChunkState(journal, relPath, chunk.Checksum, snapshot.ChunkState_VERIFIED)
+	}
+
+	// This write is ours, not a local edit - let the watcher ignore the
+	// Create/Write events it produces instead of bouncing them right back.
+	MarkExpectedWrite(path, msg.InitialFile.File.Checksum)
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		utils.LogError("Could not finalize %s: %s", path, err.Error())
+		return err
+	}
+
+	utils.LogInfo("Wrote %d bytes to %s", totalWrittenBytes, relPath)
 	return nil
 }
 
-// ApplyFileDelta reconstructs a file based on a delta message.
-func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
+// ErrSyncConflict is returned by ApplyFileDelta when msg's BaseChecksum
+// names a version this peer has already moved past, and the two edits'
+// vector clocks show they happened concurrently rather than one replaying
+// history the other already knows - see vectorClockDominates. The local
+// file is left untouched, the conflicting version is not written
+// anywhere, and a Conflict message is sent back to the delta's origin
+// peer if send is non-nil.
+var ErrSyncConflict = errors.New("service: file was edited concurrently by another peer")
+
+// ApplyFileDelta reconstructs a file based on a delta message. send is
+// used to report a detected conflict back to the delta's origin peer (see
+// ErrSyncConflict); it may be nil, in which case a conflict is still
+// detected and handled locally, just not reported over the wire.
+func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta, send func(*snapshot.WebsocketMessage) error) error {
 	oldSnapshotFile, ok := snapshot.GetSnapshot().Files[msg.FileDelta.Path]
 	if !ok {
 		// File does not appear in local register, must have been added by remote
@@ -152,6 +402,7 @@ func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
 			utils.LogError(err.Error())
 			return err
 		}
+		return nil
 	}
 
 	if bytes.Equal(oldSnapshotFile.Checksum, msg.FileDelta.Checksum) {
@@ -160,13 +411,21 @@ func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
 		return nil
 	}
 
-	oldChunkMap := make(map[[32]byte][]byte)
-	for _, chunk := range oldSnapshotFile.Chunks {
-		var checksum [32]byte
-		copy(checksum[:], chunk.Checksum)
-		oldChunkMap[checksum] = chunk.Content
+	if len(msg.FileDelta.BaseChecksum) > 0 && !bytes.Equal(msg.FileDelta.BaseChecksum, oldSnapshotFile.Checksum) &&
+		!vectorClockDominates(msg.FileDelta.VectorClock, oldSnapshotFile.VectorClock) {
+		return handleSyncConflict(msg, oldSnapshotFile, send)
+	}
+
+	if msg.FileDelta.RollingBlockSize > 0 {
+		return applyRollingFileDelta(msg, oldSnapshotFile)
 	}
 
+	// Warm the cache with the old file's chunks, capped at its per-file
+	// budget, instead of holding them all in a map for the lifetime of
+	// this call - a later moved-chunk lookup that misses falls back to
+	// chunkstore, so we don't need every chunk resident at once.
+	sharedChunkCache.WarmFile(oldSnapshotFile.Chunks, chunkstore.Get)
+
 	type reconstructionChunk struct {
 		Checksum []byte
 		Content  []byte
@@ -176,22 +435,28 @@ func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
 	// Pre-allocate slice capacity to avoid reallocations.
 	chunksForReconstruction := make([]reconstructionChunk, 0, len(msg.FileDelta.AddedChunks)+len(msg.FileDelta.MovedChunks))
 
-	// Populate the list with new chunks from the delta message.
+	// Populate the list with new chunks from the delta message, decompressing
+	// each per its Codec so everything downstream (disk, chunkstore, the
+	// snapshot) only ever sees raw content.
 	for _, added := range msg.FileDelta.AddedChunks {
+		content, err := snapshot.DecompressChunkContent(added.Content, added.Codec)
+		if err != nil {
+			return fmt.Errorf("failed to decompress added chunk %x of %s: %w", added.Checksum, msg.FileDelta.Path, err)
+		}
 		chunksForReconstruction = append(chunksForReconstruction, reconstructionChunk{
 			Checksum: added.Checksum,
-			Content:  added.Content,
+			Content:  content,
 			Offset:   added.NewOffset,
 		})
 	}
 
-	// Populate the list with moved chunks, retrieving their content from our map.
+	// Populate the list with moved chunks, retrieving their content from the cache.
 	for _, moved := range msg.FileDelta.MovedChunks {
-		content, found := oldChunkMap[[32]byte(moved.Checksum)]
-		if !found {
-			err := "Chunk with checksum '%s' was supposed to be moved, but was not found locally"
-			utils.LogError(err, moved.Checksum)
-			return fmt.Errorf(err, moved.Checksum)
+		content, err := sharedChunkCache.Get(moved.Checksum, chunkstore.Get)
+		if err != nil {
+			msg := "Chunk with checksum '%x' was supposed to be moved, but was not found locally"
+			utils.LogError(msg, moved.Checksum)
+			return fmt.Errorf(msg, moved.Checksum)
 		}
 
 		chunksForReconstruction = append(chunksForReconstruction, reconstructionChunk{
@@ -206,43 +471,107 @@ func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
 		return chunksForReconstruction[i].Offset < chunksForReconstruction[j].Offset
 	})
 
-	// Use a buffer for efficient in-memory file construction.
-	var newFileBuffer bytes.Buffer
+	var fileSize int64
+	for _, chunk := range chunksForReconstruction {
+		if end := chunk.Offset + int64(len(chunk.Content)); end > fileSize {
+			fileSize = end
+		}
+	}
+	if err := enforceReceivePolicy(relativeToSessionRoot(msg.FileDelta.Path), fileSize); err != nil {
+		utils.LogError(err.Error())
+		return err
+	}
+
+	// Reconstruct into a temp file beside the destination, writing each
+	// chunk straight to its offset instead of building the whole file in
+	// memory first. Only a verified-complete file is ever renamed into
+	// place.
+	tmpPath := msg.FileDelta.Path + ".justsync-partial"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create reconstruction file for %s: %w", msg.FileDelta.Path, err)
+	}
 	for _, chunk := range chunksForReconstruction {
-		newFileBuffer.Write(chunk.Content)
+		if _, err := tmpFile.WriteAt(chunk.Content, chunk.Offset); err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write chunk of %s: %w", msg.FileDelta.Path, err)
+		}
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to finalize reconstruction file for %s: %w", msg.FileDelta.Path, err)
 	}
-	newFileContent := newFileBuffer.Bytes()
 
 	// Verify that the reconstructed file's checksum matches the expected checksum from the delta.
-	// This guarantees the integrity of the patch operation.
+	// This guarantees the integrity of the patch operation, streaming the
+	// hash over the file instead of re-reading it into memory.
 	hasher := utils.GetHasher()
-	calculatedChecksum := hasher(newFileContent)
-	if !bytes.Equal(calculatedChecksum, msg.FileDelta.Checksum) {
-		return fmt.Errorf("checksum mismatch after applying delta for %s. Aborting.", msg.FileDelta.Path)
+	if err := verifyFileChecksum(tmpPath, msg.FileDelta.Checksum); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
 
-	// --- 5. Write to Disk and Update Snapshot ---
-	// The new content is verified. Now, write it to the filesystem.
-	if err := os.WriteFile(msg.FileDelta.Path, newFileContent, 0644); err != nil {
-		return fmt.Errorf("failed to write updated file %s: %w", msg.FileDelta.Path, err)
+	// --- 5. Conflict Detection, Versioning, Write to Disk and Update Snapshot ---
+	// If the file on disk has drifted from the snapshot we last applied a
+	// delta against, a local edit raced this one - keep both instead of
+	// silently picking a winner.
+	if localContent, err := os.ReadFile(msg.FileDelta.Path); err == nil {
+		if localChecksum := hasher(localContent); !bytes.Equal(localChecksum, oldSnapshotFile.Checksum) {
+			return writeConflictCopy(msg.FileDelta.Path, tmpPath, msg.FileDelta.Checksum, msg.FileDelta.OriginPeerId)
+		}
+	}
+
+	// No conflict: move the current version aside per the configured
+	// versioning policy, then atomically swap in the verified reconstruction.
+	if err := versioningPolicy().Version(utils.GetClientConfig().Session.Path, msg.FileDelta.Path); err != nil {
+		utils.LogError("Could not version existing file %s before overwrite: %s", msg.FileDelta.Path, err.Error())
+	}
+
+	// This rename is ours, not a local edit - let the watcher ignore the
+	// event it produces instead of bouncing it right back to the host.
+	MarkExpectedWrite(msg.FileDelta.Path, msg.FileDelta.Checksum)
+
+	if err := os.Rename(tmpPath, msg.FileDelta.Path); err != nil {
+		return fmt.Errorf("failed to finalize updated file %s: %w", msg.FileDelta.Path, err)
 	}
 
 	// Finally, update the in-memory snapshot to reflect the new state of the file.
 	// A full write lock must be acquired here to prevent any other reads or writes.
+	// Chunk content is handed to the cache and the shared chunk store here
+	// so a future delta against this same version can source it without
+	// rereading the file, and so the chunk survives a prune as long as
+	// this snapshot entry references it - but the snapshot entry itself
+	// only ever records hash/offset/size, with content always re-fetched
+	// from chunkstore on demand.
 	newSnapshotChunks := make([]*snapshot.InitialSyncChunk, len(chunksForReconstruction))
 	for i, chunk := range chunksForReconstruction {
+		sharedChunkCache.Put(chunk.Checksum, chunk.Content)
+		if err := chunkstore.Put(chunk.Checksum, chunk.Content); err != nil {
+			utils.LogError("Could not persist chunk of %s to chunk store: %s", msg.FileDelta.Path, err.Error())
+		}
+		chunkstore.Ref(chunk.Checksum)
 		newSnapshotChunks[i] = &snapshot.InitialSyncChunk{
 			Checksum: chunk.Checksum,
-			Content:  chunk.Content,
+			Offset:   chunk.Offset,
 			Size:     int64(len(chunk.Content)),
 		}
 	}
 
+	// The old version's chunks are no longer referenced by this file now
+	// that it's been replaced; chunks shared with the new version were
+	// just re-Ref'd above, so this only nets out for ones that were
+	// actually dropped.
+	for _, chunk := range oldSnapshotFile.Chunks {
+		chunkstore.Unref(chunk.Checksum)
+	}
+
 	// Write new snapshot
 	oldSnapshot := snapshot.GetSnapshot()
 	oldSnapshot.Files[msg.FileDelta.Path] = &snapshot.InitialSyncFile{
-		Checksum: msg.FileDelta.Checksum,
-		Chunks:   newSnapshotChunks,
+		Checksum:    msg.FileDelta.Checksum,
+		Chunks:      newSnapshotChunks,
+		VectorClock: mergeVectorClock(oldSnapshotFile.VectorClock, msg.FileDelta.VectorClock),
 	}
 	snapshot.WriteSnapshot(oldSnapshot)
 
@@ -250,6 +579,206 @@ func ApplyFileDelta(msg snapshot.WebsocketMessage_FileDelta) error {
 	return nil
 }
 
+// applyRollingFileDelta reconstructs msg.FileDelta.Path from
+// msg.FileDelta.RollingInstructions against oldSnapshotFile's content as
+// the basis, the rolling-hash-signature path SyncFile takes when
+// RollingDeltaEncoding is on. It mirrors ApplyFileDelta's conflict
+// detection, versioning and snapshot-update steps, but re-chunks the
+// reconstructed content with the usual CDC chunker before writing the
+// snapshot entry, so the on-disk snapshot format never has to know a
+// rolling delta was involved.
+func applyRollingFileDelta(msg snapshot.WebsocketMessage_FileDelta, oldSnapshotFile *snapshot.InitialSyncFile) error {
+	var basisSize int64
+	for _, chunk := range oldSnapshotFile.Chunks {
+		if end := chunk.Offset + chunk.Size; end > basisSize {
+			basisSize = end
+		}
+	}
+	basis := make([]byte, basisSize)
+	for _, chunk := range oldSnapshotFile.Chunks {
+		content, err := sharedChunkCache.Get(chunk.Checksum, chunkstore.Get)
+		if err != nil {
+			return fmt.Errorf("chunk %x of basis version of %s missing from chunk store: %w", chunk.Checksum, msg.FileDelta.Path, err)
+		}
+		copy(basis[chunk.Offset:], content)
+	}
+
+	content, err := utils.ApplyInstructions(msg.FileDelta.RollingInstructions, basis)
+	if err != nil {
+		return fmt.Errorf("could not apply rolling delta instructions for %s: %w", msg.FileDelta.Path, err)
+	}
+
+	if err := enforceReceivePolicy(relativeToSessionRoot(msg.FileDelta.Path), int64(len(content))); err != nil {
+		utils.LogError(err.Error())
+		return err
+	}
+
+	hasher := utils.GetHasher()
+	if got := hasher(content); !bytes.Equal(got, msg.FileDelta.Checksum) {
+		return fmt.Errorf("checksum mismatch reconstructing %s from rolling delta. Aborting.", msg.FileDelta.Path)
+	}
+
+	// If the file on disk has drifted from the snapshot we last applied a
+	// delta against, a local edit raced this one - keep both instead of
+	// silently picking a winner, same as ApplyFileDelta's CDC path.
+	if localContent, err := os.ReadFile(msg.FileDelta.Path); err == nil {
+		if localChecksum := hasher(localContent); !bytes.Equal(localChecksum, oldSnapshotFile.Checksum) {
+			tmpPath := msg.FileDelta.Path + ".justsync-partial"
+			if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+				return fmt.Errorf("failed to stage reconstructed %s before conflict handling: %w", msg.FileDelta.Path, err)
+			}
+			return writeConflictCopy(msg.FileDelta.Path, tmpPath, msg.FileDelta.Checksum, msg.FileDelta.OriginPeerId)
+		}
+	}
+
+	if err := versioningPolicy().Version(utils.GetClientConfig().Session.Path, msg.FileDelta.Path); err != nil {
+		utils.LogError("Could not version existing file %s before overwrite: %s", msg.FileDelta.Path, err.Error())
+	}
+
+	MarkExpectedWrite(msg.FileDelta.Path, msg.FileDelta.Checksum)
+	if err := os.WriteFile(msg.FileDelta.Path, content, 0644); err != nil {
+		return fmt.Errorf("failed to finalize updated file %s: %w", msg.FileDelta.Path, err)
+	}
+
+	newChunks, err := utils.ChunkFileContentDefined(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("could not re-chunk %s after applying rolling delta: %w", msg.FileDelta.Path, err)
+	}
+	for _, chunk := range newChunks {
+		chunkstore.Ref(chunk.Hash)
+	}
+	for _, chunk := range oldSnapshotFile.Chunks {
+		chunkstore.Unref(chunk.Checksum)
+	}
+
+	// The persisted snapshot entry only ever records hash/offset/size -
+	// content is already in chunkstore from the Ref loop above, and is
+	// re-fetched from there on demand rather than carried here too.
+	newSnapshotChunks := make([]*snapshot.InitialSyncChunk, len(newChunks))
+	for i, chunk := range newChunks {
+		newSnapshotChunks[i] = &snapshot.InitialSyncChunk{
+			Checksum: chunk.Hash,
+			Offset:   chunk.Offset,
+			Size:     chunk.Size,
+		}
+	}
+
+	oldSnapshot := snapshot.GetSnapshot()
+	oldSnapshot.Files[msg.FileDelta.Path] = &snapshot.InitialSyncFile{
+		Checksum:    msg.FileDelta.Checksum,
+		Chunks:      newSnapshotChunks,
+		VectorClock: mergeVectorClock(oldSnapshotFile.VectorClock, msg.FileDelta.VectorClock),
+	}
+	snapshot.WriteSnapshot(oldSnapshot)
+
+	utils.LogInfo("Successfully applied rolling delta to %s", msg.FileDelta.Path)
+	return nil
+}
+
+// verifyFileChecksum hashes the file at path by streaming it rather than
+// reading it fully into memory, and confirms it matches want.
+func verifyFileChecksum(path string, want []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to reopen %s for checksum verification: %w", path, err)
+	}
+	defer f.Close()
+
+	got, err := utils.HashReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("checksum mismatch after applying delta for %s. Aborting.", path)
+	}
+	return nil
+}
+
+// versioningPolicy resolves the configured versioning.Policy for the peer's
+// first synced folder, defaulting to PolicyNone (today's unconditional
+// overwrite behavior) when none is configured.
+func versioningPolicy() versioning.Policy {
+	folders := utils.GetClientConfig().Folders
+	if len(folders) == 0 {
+		return versioning.New(versioning.PolicyNone)
+	}
+	return versioning.New(folders[0].VersioningPolicy)
+}
+
+// writeConflictCopy keeps a remote edit that races a local one by moving
+// the already-reconstructed tmpPath alongside the original as
+// "<name>.sync-conflict-<timestamp>-<peer><ext>" and registering the copy
+// in the snapshot, instead of overwriting the local file. originPeerID is
+// the delta's FileDelta.OriginPeerId, used to name the remote side of the
+// conflict (see conflictOriginPeer).
+func writeConflictCopy(path, tmpPath string, checksum []byte, originPeerID string) error {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	conflictPath := fmt.Sprintf("%s.sync-conflict-%s-%s%s", base, time.Now().Format("20060102-150405"), conflictOriginPeer(originPeerID), ext)
+
+	if err := os.Rename(tmpPath, conflictPath); err != nil {
+		return fmt.Errorf("failed to write conflict copy %s: %w", conflictPath, err)
+	}
+
+	oldSnapshot := snapshot.GetSnapshot()
+	oldSnapshot.Files[conflictPath] = &snapshot.InitialSyncFile{Checksum: checksum}
+	snapshot.WriteSnapshot(oldSnapshot)
+
+	utils.LogWarn("Conflict detected for %s; remote version written to %s", path, conflictPath)
+	return nil
+}
+
+// conflictOriginPeer names the remote side of a conflict copy from
+// FileDelta.OriginPeerId, falling back to the generic "remote" for a
+// delta from a peer too old to stamp its identity (see SyncFile).
+func conflictOriginPeer(originPeerID string) string {
+	if originPeerID == "" {
+		return "remote"
+	}
+	return originPeerID
+}
+
+// handleSyncConflict is ApplyFileDelta's response to a BaseChecksum/
+// VectorClock pair showing the incoming delta was written concurrently
+// with a local edit this peer has already applied, rather than one
+// simply replaying history already known here (see vectorClockDominates).
+// It leaves the local file untouched, publishes EventConflictDetected,
+// and - if send is non-nil - reports the conflict back to the delta's
+// origin peer via a Conflict message so that side can surface it too.
+//
+// This only ever leaves two divergent versions - the local one here and
+// the origin peer's own - for a human to reconcile by hand; there's no UI layer in this repo
+// to drive an interactive three-way merge through, and a byte-level merge
+// would need the common ancestor's exact content, which chunkstore only
+// promises for as long as some snapshot entry still references it.
+func handleSyncConflict(msg snapshot.WebsocketMessage_FileDelta, oldSnapshotFile *snapshot.InitialSyncFile, send func(*snapshot.WebsocketMessage) error) error {
+	utils.LogWarn("Concurrent edit detected for %s from peer %s; leaving local version in place", msg.FileDelta.Path, msg.FileDelta.OriginPeerId)
+
+	Events.Publish(Event{
+		Type:   EventConflictDetected,
+		Path:   msg.FileDelta.Path,
+		PeerId: msg.FileDelta.OriginPeerId,
+	})
+
+	if send != nil {
+		conflictMsg := &snapshot.WebsocketMessage{
+			Payload: &snapshot.WebsocketMessage_Conflict{
+				Conflict: &snapshot.Conflict{
+					Path:           msg.FileDelta.Path,
+					OriginPeerId:   msg.FileDelta.OriginPeerId,
+					LocalChecksum:  oldSnapshotFile.Checksum,
+					RemoteChecksum: msg.FileDelta.Checksum,
+				},
+			},
+		}
+		if err := send(conflictMsg); err != nil {
+			utils.LogError("Could not report conflict for %s back to origin peer: %s", msg.FileDelta.Path, err.Error())
+		}
+	}
+
+	return ErrSyncConflict
+}
+
 // applyNewFileSync applies sync requests containing new files that the local register does not have.
 func applyNewFileSync(msg snapshot.WebsocketMessage_FileDelta) error {
 	if len(msg.FileDelta.MovedChunks) != 0 || len(msg.FileDelta.RemovedChunkHashes) != 0 {
@@ -260,6 +789,33 @@ func applyNewFileSync(msg snapshot.WebsocketMessage_FileDelta) error {
 		return fmt.Errorf(err, msg.FileDelta.Path)
 	}
 
+	// Decompress every chunk up front - both to know the file's final size
+	// before enforceReceivePolicy runs, and so the write loop below never
+	// has to decompress the same chunk twice.
+	type decodedChunk struct {
+		checksum []byte
+		content  []byte
+		offset   int64
+	}
+	decoded := make([]decodedChunk, len(msg.FileDelta.AddedChunks))
+	var fileSize int64
+	for i, chunk := range msg.FileDelta.AddedChunks {
+		content, err := snapshot.DecompressChunkContent(chunk.Content, chunk.Codec)
+		if err != nil {
+			utils.LogError("Could not decompress chunk %x of new file %s: %s", chunk.Checksum, msg.FileDelta.Path, err.Error())
+			return err
+		}
+		decoded[i] = decodedChunk{checksum: chunk.Checksum, content: content, offset: chunk.NewOffset}
+		if end := chunk.NewOffset + int64(len(content)); end > fileSize {
+			fileSize = end
+		}
+	}
+
+	if err := enforceReceivePolicy(relativeToSessionRoot(msg.FileDelta.Path), fileSize); err != nil {
+		utils.LogError(err.Error())
+		return err
+	}
+
 	// A valid new file was created, copy that
 	file, err := os.Create(msg.FileDelta.Path)
 	if err != nil {
@@ -267,30 +823,49 @@ func applyNewFileSync(msg snapshot.WebsocketMessage_FileDelta) error {
 		return err
 	}
 
+	// This write is ours, not a local edit - let the watcher ignore the
+	// Create/Write events it produces instead of bouncing them right back.
+	MarkExpectedWrite(msg.FileDelta.Path, msg.FileDelta.Checksum)
+
 	// Fill new file with content
 	var newChunks []*snapshot.InitialSyncChunk
-	for _, chunk := range msg.FileDelta.AddedChunks {
-		file.WriteAt(chunk.Content, chunk.NewOffset)
-
-		// Prepare for snapshot
+	for _, chunk := range decoded {
+		file.WriteAt(chunk.content, chunk.offset)
+
+		// Prepare for snapshot, and seed the chunk cache and shared chunk
+		// store so a later delta against this file can source unchanged
+		// chunks without rereading it.
+		sharedChunkCache.Put(chunk.checksum, chunk.content)
+		if err := chunkstore.Put(chunk.checksum, chunk.content); err != nil {
+			utils.LogError("Could not persist chunk of %s to chunk store: %s", msg.FileDelta.Path, err.Error())
+		}
+		chunkstore.Ref(chunk.checksum)
+		// Hash/offset/size only - content is re-fetched from chunkstore on
+		// demand, never carried in the persisted snapshot entry itself.
 		snapshotChunk := snapshot.InitialSyncChunk{
-			Checksum: chunk.Checksum,
-			Content:  chunk.Content,
-			Offset:   chunk.NewOffset,
+			Checksum: chunk.checksum,
+			Offset:   chunk.offset,
+			Size:     int64(len(chunk.content)),
 		}
 		newChunks = append(newChunks, &snapshotChunk)
 	}
 
-	// Update snapshot
-	newFileContent, err := io.ReadAll(file)
+	// Update snapshot, streaming the checksum over the file on disk rather
+	// than reading it back into memory in one shot.
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		utils.LogError("Could not rewind file that was just created via sync request. File: '%s', Error: %s", msg.FileDelta.Path, err)
+		return err
+	}
+	checksum, err := utils.HashReader(file)
 	if err != nil {
-		utils.LogError("Error retrieving content of file that was just created via sync request. File: '%s', Error: %s", msg.FileDelta.Path, err)
+		utils.LogError("Error hashing content of file that was just created via sync request. File: '%s', Error: %s", msg.FileDelta.Path, err)
 		return err
 	}
 
 	snapshotFile := snapshot.InitialSyncFile{
-		Checksum: utils.GetHasher()(newFileContent),
-		Chunks:   newChunks,
+		Checksum:    checksum,
+		Chunks:      newChunks,
+		VectorClock: msg.FileDelta.VectorClock,
 	}
 	oldSnapshot := snapshot.GetSnapshot()
 	oldSnapshot.Files[msg.FileDelta.Path] = &snapshotFile
@@ -0,0 +1,65 @@
+package service
+
+import "JustSync/internal/identity"
+
+// localPeerID identifies this device for vector-clock and FileDelta
+// OriginPeerId purposes: this node's long-lived DeviceID (see
+// internal/identity), rather than a connection-scoped ID like
+// OutboundQueue's, since an edit's origin needs to stay the same device
+// across a reconnect for vectorClockDominates comparisons to mean
+// anything. Falls back to the empty string if the identity couldn't be
+// loaded, which just means that edit can never be told apart from one
+// made before identity.Load was ever called successfully here.
+func localPeerID() string {
+	id, err := identity.Load()
+	if err != nil {
+		return ""
+	}
+	return string(id.DeviceID())
+}
+
+// mergeVectorClock returns a new vector clock recording, for every peer ID
+// either side has seen, the higher of the two counters - the clock a peer
+// holds after successfully applying a delta, since it now know everything
+// both the old local version and the incoming delta knew.
+func mergeVectorClock(a, b map[string]uint64) map[string]uint64 {
+	merged := make(map[string]uint64, len(a)+len(b))
+	for id, n := range a {
+		merged[id] = n
+	}
+	for id, n := range b {
+		if n > merged[id] {
+			merged[id] = n
+		}
+	}
+	return merged
+}
+
+// bumpVectorClock returns a copy of clock with peerID's counter
+// incremented by one, the step SyncFile takes each time this peer commits
+// a local edit - the resulting clock is what lets another peer tell this
+// edit apart from one that merely replays history it already knows.
+func bumpVectorClock(clock map[string]uint64, peerID string) map[string]uint64 {
+	bumped := make(map[string]uint64, len(clock)+1)
+	for id, n := range clock {
+		bumped[id] = n
+	}
+	bumped[peerID] = bumped[peerID] + 1
+	return bumped
+}
+
+// vectorClockDominates reports whether a has seen everything b has (every
+// counter in b is matched or exceeded in a) - meaning a delta carrying
+// clock a can be safely applied on top of a version recorded at clock b
+// even if their checksums don't literally chain, because a's sender had
+// already incorporated b's state. Two clocks where neither dominates the
+// other represent genuinely concurrent edits - see ApplyFileDelta's use of
+// this to detect an edit conflict instead of silently overwriting one.
+func vectorClockDominates(a, b map[string]uint64) bool {
+	for id, n := range b {
+		if a[id] < n {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestBumpVectorClock(t *testing.T) {
+	peerA := bumpVectorClock(nil, "peerA")
+	if peerA["peerA"] != 1 {
+		t.Fatalf("bumpVectorClock(nil, peerA)[peerA] = %d, want 1", peerA["peerA"])
+	}
+
+	peerA = bumpVectorClock(peerA, "peerA")
+	if peerA["peerA"] != 2 {
+		t.Fatalf("bumpVectorClock twice for the same peer = %d, want 2", peerA["peerA"])
+	}
+
+	// Bumping must not mutate the clock passed in - the caller (SyncFile)
+	// still needs the old snapshot's clock around after this call.
+	original := map[string]uint64{"peerA": 1}
+	snapshot := maps.Clone(original)
+	_ = bumpVectorClock(original, "peerB")
+	if !maps.Equal(original, snapshot) {
+		t.Errorf("bumpVectorClock mutated its input: got %v, want unchanged %v", original, snapshot)
+	}
+}
+
+func TestMergeVectorClock(t *testing.T) {
+	// Two peers who've each only ever bumped their own counter.
+	a := map[string]uint64{"peerA": 2}
+	b := map[string]uint64{"peerB": 1}
+
+	merged := mergeVectorClock(a, b)
+	want := map[string]uint64{"peerA": 2, "peerB": 1}
+	if !maps.Equal(merged, want) {
+		t.Errorf("mergeVectorClock(%v, %v) = %v, want %v", a, b, merged, want)
+	}
+
+	// Overlapping peer IDs: the higher counter on either side wins.
+	a = map[string]uint64{"peerA": 3, "peerB": 1}
+	b = map[string]uint64{"peerA": 1, "peerB": 5}
+	merged = mergeVectorClock(a, b)
+	want = map[string]uint64{"peerA": 3, "peerB": 5}
+	if !maps.Equal(merged, want) {
+		t.Errorf("mergeVectorClock(%v, %v) = %v, want %v", a, b, merged, want)
+	}
+}
+
+func TestVectorClockDominates(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]uint64
+		want bool
+	}{
+		{
+			name: "identical clocks dominate each other",
+			a:    map[string]uint64{"peerA": 1},
+			b:    map[string]uint64{"peerA": 1},
+			want: true,
+		},
+		{
+			name: "a has already merged in everything b knows",
+			a:    map[string]uint64{"peerA": 2, "peerB": 1},
+			b:    map[string]uint64{"peerA": 2},
+			want: true,
+		},
+		{
+			name: "a is missing an update to a peer b has seen",
+			a:    map[string]uint64{"peerA": 1},
+			b:    map[string]uint64{"peerA": 1, "peerB": 1},
+			want: false,
+		},
+		{
+			name: "diverged: each side bumped its own counter independently",
+			a:    map[string]uint64{"peerA": 2, "peerB": 1},
+			b:    map[string]uint64{"peerA": 1, "peerB": 2},
+			want: false,
+		},
+		{
+			name: "empty b is trivially dominated by anything",
+			a:    map[string]uint64{},
+			b:    map[string]uint64{},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vectorClockDominates(tt.a, tt.b); got != tt.want {
+				t.Errorf("vectorClockDominates(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVectorClockRoundTrip_TwoPeersConverge models the full lifecycle two
+// peers' clocks go through: each starts empty, peer A commits a local
+// edit, peer B later receives and applies that edit (merging clocks), then
+// peer B commits its own edit on top. The result must dominate peer A's
+// original clock, since B's edit has now incorporated everything A sent -
+// confirming a later delta from B would apply cleanly rather than being
+// flagged as a conflict.
+func TestVectorClockRoundTrip_TwoPeersConverge(t *testing.T) {
+	clockA := bumpVectorClock(nil, "peerA")
+
+	clockB := mergeVectorClock(nil, clockA)
+	clockB = bumpVectorClock(clockB, "peerB")
+
+	if !vectorClockDominates(clockB, clockA) {
+		t.Errorf("peer B's clock %v should dominate peer A's %v after merging and bumping", clockB, clockA)
+	}
+}
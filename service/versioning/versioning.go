@@ -0,0 +1,222 @@
+// Package versioning implements JustSync's file versioning/trash policies,
+// mirroring Syncthing's simple/staggered/trash-can versioners: before a
+// local file is overwritten by an incoming sync, the current content is
+// moved aside instead of being destroyed.
+package versioning
+
+import (
+	"JustSync/pkg"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// PolicyNone overwrites files in place with no history kept.
+	PolicyNone = "none"
+	// PolicyTrash keeps every previous version, with no retention pruning.
+	PolicyTrash = "trash"
+	// PolicySimple keeps the KeepVersions most recent versions of a file.
+	PolicySimple = "simple"
+	// PolicyStaggered buckets versions into 1h/1d/1w/1mo, keeping the most
+	// recent version in each bucket.
+	PolicyStaggered = "staggered"
+)
+
+// KeepVersions is the retention count used by PolicySimple.
+const KeepVersions = 5
+
+var stagger = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+var log = pkg.NewLogger("versioning")
+
+// Policy moves a file that is about to be overwritten out of the way, into
+// whatever retention scheme the policy implements. root is the synced
+// project's root directory; path is absolute and must be inside root.
+type Policy interface {
+	Version(root, path string) error
+}
+
+// New returns the Policy named by policy, defaulting to PolicyNone for an
+// empty or unrecognized name so a missing config value behaves like today's
+// unconditional overwrite.
+func New(policy string) Policy {
+	switch policy {
+	case PolicyTrash:
+		return trashPolicy{}
+	case PolicySimple:
+		return simplePolicy{}
+	case PolicyStaggered:
+		return staggeredPolicy{}
+	default:
+		return nonePolicy{}
+	}
+}
+
+type nonePolicy struct{}
+
+func (nonePolicy) Version(root, path string) error { return nil }
+
+// versionsDir is where every policy except PolicyNone stores moved-aside
+// versions, mirroring the synced project's own relative layout underneath.
+func versionsDir(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, ".justsync", "versions", filepath.Dir(rel)), nil
+}
+
+// moveAside renames the file at path into its versions directory, tagged
+// with the given timestamp, creating the directory if needed. It is a
+// no-op if path does not exist yet (a brand new file has nothing to
+// version).
+func moveAside(root, path string, at time.Time) (string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	dir, err := versionsDir(root, path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(path), at.Format("20060102-150405")))
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+
+	log.Debug("Versioned %s -> %s", path, dest)
+	return dest, nil
+}
+
+type trashPolicy struct{}
+
+func (trashPolicy) Version(root, path string) error {
+	_, err := moveAside(root, path, time.Now())
+	return err
+}
+
+type simplePolicy struct{}
+
+func (simplePolicy) Version(root, path string) error {
+	if _, err := moveAside(root, path, time.Now()); err != nil {
+		return err
+	}
+	return pruneToCount(root, path, KeepVersions)
+}
+
+// pruneToCount deletes all but the keep most recent versions of path.
+func pruneToCount(root, path string, keep int) error {
+	versions, err := listVersions(root, path)
+	if err != nil {
+		return err
+	}
+	if len(versions) <= keep {
+		return nil
+	}
+
+	for _, v := range versions[:len(versions)-keep] {
+		if err := os.Remove(v); err != nil {
+			log.Warn("Could not prune old version %s: %s", v, err.Error())
+		}
+	}
+	return nil
+}
+
+type staggeredPolicy struct{}
+
+// Version moves the current file aside, then keeps only the newest version
+// falling in each of the 1h/1d/1w/1mo buckets (by age at the time of this
+// call), discarding the rest.
+func (staggeredPolicy) Version(root, path string) error {
+	now := time.Now()
+	if _, err := moveAside(root, path, now); err != nil {
+		return err
+	}
+
+	versions, err := listVersions(root, path)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[int]string)
+	for _, v := range versions {
+		ts, err := versionTimestamp(path, v)
+		if err != nil {
+			continue
+		}
+
+		bucket := staggerBucket(now.Sub(ts))
+		if existing, ok := kept[bucket]; !ok || ts.After(mustTimestamp(path, existing)) {
+			kept[bucket] = v
+		}
+	}
+
+	for _, v := range versions {
+		keep := false
+		for _, k := range kept {
+			if k == v {
+				keep = true
+				break
+			}
+		}
+		if !keep {
+			if err := os.Remove(v); err != nil {
+				log.Warn("Could not prune stale version %s: %s", v, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func staggerBucket(age time.Duration) int {
+	for i, d := range stagger {
+		if age <= d {
+			return i
+		}
+	}
+	return len(stagger)
+}
+
+func listVersions(root, path string) ([]string, error) {
+	dir, err := versionsDir(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(path) + "."
+	var versions []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			versions = append(versions, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	sort.Strings(versions) // timestamp suffix sorts chronologically
+	return versions, nil
+}
+
+func versionTimestamp(path, versionPath string) (time.Time, error) {
+	suffix := strings.TrimPrefix(filepath.Base(versionPath), filepath.Base(path)+".")
+	return time.Parse("20060102-150405", suffix)
+}
+
+func mustTimestamp(path, versionPath string) time.Time {
+	ts, _ := versionTimestamp(path, versionPath)
+	return ts
+}
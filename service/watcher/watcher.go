@@ -0,0 +1,358 @@
+// Package watcher drives automatic sync for a host-mode peer. It watches a
+// project directory recursively with fsnotify and, on a local edit, calls
+// the same chunk-diffing pipeline the manual /send-sync endpoint uses
+// (service.SyncFile) after a short per-path debounce, so editor saves that
+// touch a file several times in a row only trigger one sync.
+package watcher
+
+import (
+	"JustSync/filter"
+	"JustSync/pkg"
+	"JustSync/service"
+	"JustSync/snapshot"
+	"JustSync/utils"
+	socket "JustSync/websocket"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DebounceWindow is how long Watcher waits after the last event for a path
+// before acting on it, so a burst of saves (or a write-then-rename from an
+// editor) collapses into a single sync.
+const DebounceWindow = 200 * time.Millisecond
+
+// defaultIgnorePatterns are skipped regardless of the configured
+// exclude/include filter - transient files editors create around a save
+// that should never reach the host as a real change.
+var defaultIgnorePatterns = []string{
+	"*.swp", "*.swx", "*.swpx", "*~", ".#*", "#*#", "4913", ".goutputstream-*",
+}
+
+var defaultIgnoreMatch = filter.Compile(defaultIgnorePatterns)
+
+var log = pkg.NewLogger("watcher")
+
+type pendingRemoval struct {
+	path     string
+	checksum string // hex, empty if unknown
+	timer    *time.Timer
+}
+
+// Watcher recursively watches a project root and drives Sync automatically
+// as files change underneath it.
+type Watcher struct {
+	fsw     *fsnotify.Watcher
+	root    string
+	match   *filter.Matcher
+	done    chan struct{}
+	closeMu sync.Once
+
+	mu                sync.Mutex
+	debounced         map[string]*time.Timer
+	pendingByPath     map[string]*pendingRemoval
+	pendingByChecksum map[string]*pendingRemoval
+}
+
+// New starts watching root (and every directory created under it) for
+// changes, ignoring any path match excludes (see package filter, built
+// from root's .justsyncignore plus the configured exclude/include lists)
+// in addition to the built-in editor-temp-file patterns and any symlink.
+func New(root string, match *filter.Matcher) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watcher: could not start fsnotify: %w", err)
+	}
+
+	w := &Watcher{
+		fsw:               fsw,
+		root:              root,
+		match:             match,
+		done:              make(chan struct{}),
+		debounced:         make(map[string]*time.Timer),
+		pendingByPath:     make(map[string]*pendingRemoval),
+		pendingByChecksum: make(map[string]*pendingRemoval),
+	}
+
+	if err := w.watchTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Run processes filesystem events until Close is called. Callers should run
+// it in its own goroutine.
+func (w *Watcher) Run() {
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Warn("fsnotify error: %s", err.Error())
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Close stops Run and releases the underlying fsnotify watches.
+func (w *Watcher) Close() error {
+	w.closeMu.Do(func() { close(w.done) })
+	return w.fsw.Close()
+}
+
+// watchTree adds an fsnotify watch for dir and every non-ignored
+// subdirectory beneath it.
+func (w *Watcher) watchTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("watcher: could not watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) handleEvent(event fsnotify.Event) {
+	if w.ignored(event.Name) {
+		return
+	}
+
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		w.handleCreate(event.Name)
+	case event.Op&fsnotify.Write != 0:
+		w.scheduleSync(event.Name)
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		w.handleGone(event.Name)
+	}
+}
+
+// handleCreate re-watches new directories so moving a whole tree in stays
+// covered, and otherwise either pairs the create with a just-removed path
+// of identical content (a rename) or schedules a normal sync.
+func (w *Watcher) handleCreate(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Gone again already, e.g. an editor's tmp file created and removed
+		// within the same debounce window. Nothing to do.
+		return
+	}
+
+	if info.IsDir() {
+		if err := w.watchTree(path); err != nil {
+			log.Warn("Could not watch new directory %s: %s", path, err.Error())
+		}
+		return
+	}
+
+	if w.resolveAsRename(path) {
+		return
+	}
+
+	w.scheduleSync(path)
+}
+
+// resolveAsRename reports whether path's content matches a path that was
+// removed or renamed-away within the debounce window, and if so cancels
+// that pending removal and emits a FileRenamed instead of treating path as
+// an unrelated new file.
+func (w *Watcher) resolveAsRename(path string) bool {
+	hash, err := hashFile(path)
+	if err != nil {
+		return false
+	}
+	key := hex.EncodeToString(hash)
+
+	w.mu.Lock()
+	pr, ok := w.pendingByChecksum[key]
+	if ok {
+		pr.timer.Stop()
+		delete(w.pendingByChecksum, key)
+		delete(w.pendingByPath, pr.path)
+	}
+	w.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	if err := socket.SendToHost(&snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_FileRenamed{
+			FileRenamed: &snapshot.FileRenamed{OldPath: pr.path, NewPath: path},
+		},
+	}); err != nil {
+		log.Warn("Could not send rename of %s -> %s: %s", pr.path, path, err.Error())
+	}
+	return true
+}
+
+// handleGone debounces a Remove/Rename event for path, giving a paired
+// Create event (an editor's rename-into-place, or a real rename) a chance
+// to arrive and resolve it before it's reported as a removal.
+func (w *Watcher) handleGone(path string) {
+	w.mu.Lock()
+	if _, exists := w.pendingByPath[path]; exists {
+		w.mu.Unlock()
+		return
+	}
+
+	checksum := hex.EncodeToString(lastKnownChecksum(path))
+	pr := &pendingRemoval{path: path, checksum: checksum}
+	w.pendingByPath[path] = pr
+	if checksum != "" {
+		w.pendingByChecksum[checksum] = pr
+	}
+	w.mu.Unlock()
+
+	pr.timer = time.AfterFunc(DebounceWindow, func() { w.finalizeRemoval(pr) })
+}
+
+func (w *Watcher) finalizeRemoval(pr *pendingRemoval) {
+	w.mu.Lock()
+	if _, exists := w.pendingByPath[pr.path]; !exists {
+		// Resolved as a rename while we were waiting.
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pendingByPath, pr.path)
+	if pr.checksum != "" {
+		delete(w.pendingByChecksum, pr.checksum)
+	}
+	w.mu.Unlock()
+
+	if err := socket.SendToHost(&snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_FileRemoved{
+			FileRemoved: &snapshot.FileRemoved{Path: pr.path},
+		},
+	}); err != nil {
+		log.Warn("Could not send removal of %s: %s", pr.path, err.Error())
+	}
+}
+
+// scheduleSync debounces path's Write/Create events so a burst of saves
+// only syncs once, DebounceWindow after the last one.
+func (w *Watcher) scheduleSync(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t, ok := w.debounced[path]; ok {
+		t.Reset(DebounceWindow)
+		return
+	}
+	w.debounced[path] = time.AfterFunc(DebounceWindow, func() {
+		w.mu.Lock()
+		delete(w.debounced, path)
+		w.mu.Unlock()
+		w.syncNow(path)
+	})
+}
+
+func (w *Watcher) syncNow(path string) {
+	if hash, err := hashFile(path); err == nil && service.ConsumeExpectedWrite(path, hash) {
+		log.Debug("Skipping echo of our own write to %s", path)
+		return
+	}
+
+	msg, err := service.SyncFile(path)
+	if errors.Is(err, service.ErrCollaborativeFile) {
+		w.syncCollaborative(path)
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, service.ErrNoChange) {
+			log.Warn("Could not sync %s: %s", path, err.Error())
+		}
+		return
+	}
+
+	if err := socket.SendToHost(msg); err != nil {
+		log.Warn("Could not send sync of %s: %s", path, err.Error())
+	}
+}
+
+// syncCollaborative hands path's local edit to the CRDT path instead -
+// SyncFile's CDC deltas don't apply to a collaborative-mode folder, but
+// the edit still needs to reach other peers, just as a YUpdate rather
+// than a FileDelta.
+func (w *Watcher) syncCollaborative(path string) {
+	msg, err := service.SyncCollaborativeFile(path)
+	if err != nil {
+		if !errors.Is(err, service.ErrNoChange) {
+			log.Warn("Could not sync collaborative file %s: %s", path, err.Error())
+		}
+		return
+	}
+
+	if err := socket.SendToHost(msg); err != nil {
+		log.Warn("Could not send collaborative sync of %s: %s", path, err.Error())
+	}
+}
+
+// ignored reports whether path sits under the .justsync bookkeeping
+// directory, is a symlink, or matches w.match or one of the built-in
+// editor-temp-file patterns.
+func (w *Watcher) ignored(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		rel = path
+	}
+	if rel == ".justsync" || strings.HasPrefix(rel, ".justsync"+string(filepath.Separator)) {
+		return true
+	}
+
+	info, statErr := os.Lstat(path)
+	if statErr == nil && info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+
+	isDir := statErr == nil && info.IsDir()
+	return defaultIgnoreMatch.Match(rel, isDir) || w.match.Match(rel, isDir)
+}
+
+// lastKnownChecksum looks up path's checksum in the current snapshot, or
+// returns nil if the file has no tracked history (e.g. it was never synced
+// before being removed).
+func lastKnownChecksum(path string) []byte {
+	snap := snapshot.GetSnapshot()
+	if snap == nil {
+		return nil
+	}
+	if f, ok := snap.Files[path]; ok {
+		return f.Checksum
+	}
+	return nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return utils.HashReader(f)
+}
@@ -0,0 +1,25 @@
+package snapshot
+
+import "JustSync/utils/compress"
+
+// CompressChunkContent zstd-compresses content for the wire at the
+// configured SetCompressionLevel, returning the codec the caller should tag
+// the chunk with. It reports ChunkCodec_NONE, and content unchanged, when
+// compressing wasn't worthwhile (see utils/compress) - callers always send
+// both the returned bytes and codec together.
+func CompressChunkContent(content []byte) (out []byte, codec ChunkCodec) {
+	out, ok := compress.Compress(content, compressionLevel)
+	if !ok {
+		return content, ChunkCodec_NONE
+	}
+	return out, ChunkCodec_ZSTD
+}
+
+// DecompressChunkContent reverses CompressChunkContent according to codec,
+// passing content through unchanged for ChunkCodec_NONE.
+func DecompressChunkContent(content []byte, codec ChunkCodec) ([]byte, error) {
+	if codec != ChunkCodec_ZSTD {
+		return content, nil
+	}
+	return compress.Decompress(content)
+}
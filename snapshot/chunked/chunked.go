@@ -0,0 +1,324 @@
+// Package chunked implements an on-disk blob layout inspired by the
+// zstd:chunked format: a file's chunks are each compressed as an
+// independently decodable zstd frame, concatenated together, and
+// followed by a zstd skippable frame carrying a table of contents that
+// maps each chunk's hash to its placement. A peer that already has most
+// of a file's chunks can read just the trailer and TOC, then issue
+// range fetches for only the frames it's missing instead of pulling the
+// whole blob.
+package chunked
+
+import (
+	"JustSync/pkg"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// skippableMagic is a zstd skippable-frame magic number (the format
+// reserves 0x184D2A50-0x184D2A5F for exactly this kind of side-channel
+// payload) used both for the TOC frame itself and, doubled up, as the
+// final 8-byte trailer so a reader can locate it with one tail read.
+const skippableMagic uint32 = 0x184D2A50
+
+// trailerSize is the fixed-size footer: 4 bytes of magic plus a 4-byte
+// length for the skippable TOC frame that precedes it.
+const trailerSize = 8
+
+var log = pkg.NewLogger("chunked")
+
+// Entry describes one chunk's placement inside a blob.
+type Entry struct {
+	Hash               string // hex-encoded blake3 hash of the uncompressed chunk
+	CompressedOffset   int64
+	CompressedSize     int64
+	UncompressedOffset int64
+	UncompressedSize   int64
+}
+
+// TOC maps every chunk of a blob to its Entry, in the order they were
+// written.
+//
+// TODO: this is gob-encoded rather than the protobuf message the design
+// calls for, because this snapshot of the repo has no protoc toolchain to
+// regenerate .pb.go from a .proto. Swap the encoding for a generated
+// message once that's available; the skippable-frame + trailer layout
+// around it doesn't need to change.
+type TOC struct {
+	Entries []Entry
+}
+
+// RangeFetcher fetches length bytes starting at offset from a blob,
+// whatever is backing it - a local file today, an HTTP range request or
+// a peer connection once a peer serves blobs remotely.
+type RangeFetcher interface {
+	FetchRange(offset, length int64) ([]byte, error)
+}
+
+// FileFetcher is a RangeFetcher over a local *os.File.
+type FileFetcher struct {
+	f *os.File
+}
+
+// NewFileFetcher wraps f as a RangeFetcher.
+func NewFileFetcher(f *os.File) *FileFetcher {
+	return &FileFetcher{f: f}
+}
+
+func (ff *FileFetcher) FetchRange(offset, length int64) ([]byte, error) {
+	buf := make([]byte, length)
+	if _, err := ff.f.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// HTTPFetcher is a RangeFetcher over a blob served by a peer's HTTP
+// endpoint (the InitialSyncFileWithPath's BlobUrl), using a
+// standard Range header so the peer only has to pay for the bytes of
+// the frames it actually asked for.
+type HTTPFetcher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPFetcher returns a RangeFetcher that issues Range requests
+// against url, using http.DefaultClient if client is nil.
+func NewHTTPFetcher(url string, client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFetcher{url: url, client: client}
+}
+
+func (hf *HTTPFetcher) FetchRange(offset, length int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, hf.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := hf.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("chunked: expected 206 Partial Content from %s, got %s", hf.url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// Write compresses each of chunks (in order, hashed by the matching
+// entry in hashes) as an independent zstd frame into w, then appends the
+// TOC as a skippable frame and the 8-byte trailer. It returns the TOC so
+// the caller can hold onto it without immediately re-reading the blob.
+func Write(w io.Writer, chunks [][]byte, hashes [][]byte) (*TOC, error) {
+	if len(chunks) != len(hashes) {
+		return nil, errors.New("chunked: chunks and hashes must be the same length")
+	}
+
+	toc := &TOC{Entries: make([]Entry, 0, len(chunks))}
+	var compressedOffset, uncompressedOffset int64
+
+	for i, data := range chunks {
+		frame, err := compressFrame(data)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(frame); err != nil {
+			return nil, err
+		}
+
+		toc.Entries = append(toc.Entries, Entry{
+			Hash:               hex.EncodeToString(hashes[i]),
+			CompressedOffset:   compressedOffset,
+			CompressedSize:     int64(len(frame)),
+			UncompressedOffset: uncompressedOffset,
+			UncompressedSize:   int64(len(data)),
+		})
+
+		compressedOffset += int64(len(frame))
+		uncompressedOffset += int64(len(data))
+	}
+
+	tocBytes, err := encodeTOC(toc)
+	if err != nil {
+		return nil, err
+	}
+
+	skippable := make([]byte, 8+len(tocBytes))
+	binary.LittleEndian.PutUint32(skippable[0:4], skippableMagic)
+	binary.LittleEndian.PutUint32(skippable[4:8], uint32(len(tocBytes)))
+	copy(skippable[8:], tocBytes)
+	if _, err := w.Write(skippable); err != nil {
+		return nil, err
+	}
+
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint32(trailer[0:4], skippableMagic)
+	binary.LittleEndian.PutUint32(trailer[4:8], uint32(len(skippable)))
+	if _, err := w.Write(trailer); err != nil {
+		return nil, err
+	}
+
+	log.Debug("Wrote chunked blob with %d chunks", len(toc.Entries))
+	return toc, nil
+}
+
+// ReadTOC recovers the TOC from the tail of a blob of the given total
+// size, fetching only the trailer and the TOC frame itself rather than
+// the whole blob.
+func ReadTOC(fetcher RangeFetcher, blobSize int64) (*TOC, error) {
+	if blobSize < trailerSize {
+		return nil, errors.New("chunked: blob too small to contain a trailer")
+	}
+
+	trailer, err := fetcher.FetchRange(blobSize-trailerSize, trailerSize)
+	if err != nil {
+		return nil, err
+	}
+	if magic := binary.LittleEndian.Uint32(trailer[0:4]); magic != skippableMagic {
+		return nil, fmt.Errorf("chunked: trailer magic mismatch, got %#x", magic)
+	}
+
+	skippableLen := int64(binary.LittleEndian.Uint32(trailer[4:8]))
+	if skippableLen < 8 || skippableLen > blobSize-trailerSize {
+		return nil, errors.New("chunked: invalid TOC frame length in trailer")
+	}
+
+	skippable, err := fetcher.FetchRange(blobSize-trailerSize-skippableLen, skippableLen)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeTOC(skippable)
+}
+
+// DecodeTOC parses a TOC from the raw bytes of its skippable frame
+// (magic + length header included), for a caller that already has those
+// bytes in hand - e.g. forwarded inline alongside a sync message -
+// without needing a RangeFetcher to go re-fetch them.
+func DecodeTOC(skippable []byte) (*TOC, error) {
+	if len(skippable) < 8 {
+		return nil, errors.New("chunked: skippable frame too small")
+	}
+	if magic := binary.LittleEndian.Uint32(skippable[0:4]); magic != skippableMagic {
+		return nil, fmt.Errorf("chunked: skippable frame magic mismatch, got %#x", magic)
+	}
+
+	tocLen := int64(binary.LittleEndian.Uint32(skippable[4:8]))
+	if 8+tocLen > int64(len(skippable)) {
+		return nil, errors.New("chunked: TOC length exceeds its frame")
+	}
+
+	return decodeTOC(skippable[8 : 8+tocLen])
+}
+
+// Missing returns the entries of toc whose hash is not present in local,
+// the caller's set of chunk hashes already held somewhere else (usually
+// service/chunkstore) - the entries worth range-fetching.
+func (toc *TOC) Missing(local map[string]bool) []Entry {
+	var missing []Entry
+	for _, e := range toc.Entries {
+		if !local[e.Hash] {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// ChunkedReader reconstructs a file from a blob by fetching and
+// decompressing only the frames its caller asks for, so a peer that
+// already holds most of a file's chunks locally never has to download
+// them again.
+type ChunkedReader struct {
+	fetcher RangeFetcher
+	toc     *TOC
+}
+
+// NewChunkedReader pairs fetcher with an already-parsed TOC.
+func NewChunkedReader(fetcher RangeFetcher, toc *TOC) *ChunkedReader {
+	return &ChunkedReader{fetcher: fetcher, toc: toc}
+}
+
+// WriteTo reconstructs the full file into w by fetching and decompressing
+// every entry in order.
+func (r *ChunkedReader) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, e := range r.toc.Entries {
+		data, err := r.FetchChunk(e)
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// FetchChunk fetches and decompresses a single entry's frame.
+func (r *ChunkedReader) FetchChunk(e Entry) ([]byte, error) {
+	frame, err := r.fetcher.FetchRange(e.CompressedOffset, e.CompressedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := zstd.NewReader(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	var buf bytes.Buffer
+	buf.Grow(int(e.UncompressedSize))
+	if _, err := io.Copy(&buf, dec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compressFrame(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	enc, err := zstd.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := enc.Write(data); err != nil {
+		enc.Close()
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeTOC(toc *TOC) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(toc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeTOC(data []byte) (*TOC, error) {
+	var toc TOC
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&toc); err != nil {
+		return nil, err
+	}
+	return &toc, nil
+}
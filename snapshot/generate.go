@@ -0,0 +1,9 @@
+package snapshot
+
+// The wire types referenced throughout this package and its callers
+// (ProjectSnapshot, WebsocketMessage, FileDelta, TransferJournal, ...) are
+// generated from sync.proto into sync.pb.go - run this directive with
+// protoc and protoc-gen-go (`go install
+// google.golang.org/protobuf/cmd/protoc-gen-go@latest`) on PATH to
+// regenerate sync.pb.go after changing sync.proto.
+//go:generate protoc --go_out=. --go_opt=paths=source_relative sync.proto
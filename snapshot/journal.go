@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// JournalPath is where the in-progress transfer journal is persisted,
+// alongside the project snapshot itself.
+const JournalPath = "snapshot/TRANSFER.journal"
+
+var journalMu sync.Mutex
+
+// LoadJournal reads the transfer journal for sessionID from disk, returning
+// a fresh empty journal if none is persisted yet or the persisted one
+// belongs to a different session.
+func LoadJournal(sessionID string) *TransferJournal {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	data, err := os.ReadFile(JournalPath)
+	if err != nil {
+		return &TransferJournal{SessionId: sessionID}
+	}
+
+	var j TransferJournal
+	if err := proto.Unmarshal(data, &j); err != nil || j.SessionId != sessionID {
+		return &TransferJournal{SessionId: sessionID}
+	}
+	return &j
+}
+
+// WriteJournal persists j to disk and fsyncs before returning, so a crash
+// immediately after a chunk state transition can't silently lose it.
+func WriteJournal(j *TransferJournal) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	data, err := proto.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(JournalPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// MarkChunkState records (or updates) filePath/chunkHash's state in j and
+// persists the journal, so the next resume knows exactly how far this
+// chunk got.
+func MarkChunkState(j *TransferJournal, filePath string, chunkHash []byte, state ChunkState) error {
+	for _, entry := range j.Entries {
+		if entry.FilePath == filePath && bytes.Equal(entry.ChunkHash, chunkHash) {
+			entry.State = state
+			return WriteJournal(j)
+		}
+	}
+	j.Entries = append(j.Entries, &ChunkProgress{
+		FilePath:  filePath,
+		ChunkHash: chunkHash,
+		State:     state,
+	})
+	return WriteJournal(j)
+}
+
+// HaveChunks returns the hash of every chunk j records as verified, the set
+// a ResumeRequest reports so the host can skip re-sending their content.
+func HaveChunks(j *TransferJournal) [][]byte {
+	var have [][]byte
+	for _, entry := range j.Entries {
+		if entry.State == ChunkState_VERIFIED {
+			have = append(have, entry.ChunkHash)
+		}
+	}
+	return have
+}
+
+// DeleteJournal removes the persisted journal. Call it once a session
+// finishes cleanly and resuming it no longer means anything.
+func DeleteJournal() error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	if err := os.Remove(JournalPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
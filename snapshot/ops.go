@@ -1,6 +1,7 @@
 package snapshot
 
 import (
+	"JustSync/utils/compress"
 	"os"
 	"sync"
 
@@ -14,12 +15,26 @@ const (
 var (
 	snapshot   *ProjectSnapshot
 	snapshotMu sync.Mutex
+
+	compressionLevel = compress.LevelDefault
 )
 
+// SetCompressionLevel controls how hard WriteSnapshot tries to compress
+// the snapshot file, per the host/client config's compressionLevel
+// setting. It has no effect on reading - LoadSnapshot always sniffs the
+// zstd magic number rather than trusting a flag, so snapshots written
+// before this existed still load fine.
+func SetCompressionLevel(level compress.Level) {
+	compressionLevel = level
+}
+
 func GetSnapshot() *ProjectSnapshot {
 	return snapshot
 }
 
+// WriteSnapshot persists snap to SnapPath, zstd-framing the marshaled
+// bytes when doing so is worthwhile (see utils/compress), and updates the
+// in-memory snapshot returned by GetSnapshot to match.
 func WriteSnapshot(snap *ProjectSnapshot) error {
 	snapshotMu.Lock()
 	defer snapshotMu.Unlock()
@@ -29,7 +44,35 @@ func WriteSnapshot(snap *ProjectSnapshot) error {
 		return err
 	}
 
+	data, _ = compress.Compress(data, compressionLevel)
+
 	snapshot = snap
 
 	return os.WriteFile(SnapPath, data, 0644)
 }
+
+// LoadSnapshot reads SnapPath back in, transparently decompressing it if
+// it was zstd-framed, and sets it as the in-memory snapshot GetSnapshot
+// returns.
+func LoadSnapshot() (*ProjectSnapshot, error) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	data, err := os.ReadFile(SnapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = compress.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &ProjectSnapshot{}
+	if err := proto.Unmarshal(data, snap); err != nil {
+		return nil, err
+	}
+
+	snapshot = snap
+	return snap, nil
+}
@@ -0,0 +1,118 @@
+// Package compress wraps klauspost/zstd to transparently compress
+// on-disk snapshot files and individual wire chunks, while staying a
+// no-op for data compression wouldn't help - small payloads, and data
+// that's already dense enough that a zstd frame would only add overhead.
+package compress
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Level selects a compression/speed tradeoff, independent of the
+// underlying zstd library's own enum so callers (and the YAML config)
+// don't need to import it directly.
+type Level int
+
+const (
+	LevelFastest Level = iota
+	LevelDefault
+	LevelBetter
+	LevelBest
+)
+
+func (l Level) zstdLevel() zstd.EncoderLevel {
+	switch l {
+	case LevelBetter:
+		return zstd.SpeedBetterCompression
+	case LevelBest:
+		return zstd.SpeedBestCompression
+	case LevelFastest:
+		return zstd.SpeedFastest
+	default:
+		return zstd.SpeedDefault
+	}
+}
+
+// minSize is the smallest input worth attempting to compress - zstd's
+// frame header overhead eats into or exceeds any saving below this.
+const minSize = 512
+
+// maxEntropy is a rough Shannon-entropy cutoff, in bits per byte, above
+// which data is almost certainly already compressed or random, so
+// attempting to zstd it would just burn CPU for no saving.
+const maxEntropy = 7.5
+
+// magic is the zstd frame magic number every frame starts with, used to
+// sniff whether on-disk/wire data is zstd-compressed without needing a
+// separate flag for data written before compression support existed.
+var magic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// IsCompressed reports whether data opens with a zstd frame magic number.
+func IsCompressed(data []byte) bool {
+	return len(data) >= len(magic) && bytes.Equal(data[:len(magic)], magic)
+}
+
+// Compress zstd-compresses data at level, unless it's too small or too
+// high-entropy to plausibly benefit, or the result doesn't actually end up
+// smaller. ok reports whether compressed was returned; callers should
+// store/send data as-is when it's false.
+func Compress(data []byte, level Level) (compressed []byte, ok bool) {
+	if len(data) < minSize || entropy(data) > maxEntropy {
+		return data, false
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level.zstdLevel()))
+	if err != nil {
+		return data, false
+	}
+	defer enc.Close()
+
+	out := enc.EncodeAll(data, nil)
+	if len(out) >= len(data) {
+		return data, false
+	}
+	return out, true
+}
+
+// Decompress reverses Compress. It passes data through unchanged if it
+// doesn't start with the zstd magic number, so callers can run every read
+// through it regardless of whether the other side actually compressed.
+func Decompress(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	return dec.DecodeAll(data, nil)
+}
+
+// entropy estimates data's Shannon entropy in bits per byte.
+func entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var h float64
+	n := float64(len(data))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -1,97 +1,43 @@
+// These names are kept as aliases of the canonical JustSync/internal/config
+// types so existing callers importing utils for config don't need to
+// change, while there is now only one schema, one singleton, and one YAML
+// parser behind them.
 package utils
 
-import (
-	"fmt"
-	"strings"
-	"sync"
-)
+import "JustSync/internal/config"
 
-type RunMode string
+type RunMode = config.RunMode
 
-type ExternalClientConfig struct {
-	Session struct {
-		Port string `yaml:"port"`
-		Name string `yaml:"name"`
-		Path string `yaml:"path"`
-		Host struct {
-			Url string `yaml:"url"`
-		}
-		Client struct {
-			Name  string `yaml:"name"`
-			Token string `yaml:"token"`
-		}
-	}
-}
+type ExternalClientConfig = config.PeerConfig
 
-type ExternalHostConfig struct {
-	Application struct {
-		Port         string   `yaml:"port"`
-		Path         string   `yaml:"path"`
-		IgnoredFiles []string `yaml:"ignoredFiles"`
-	}
-}
+type ExternalHostConfig = config.ServerConfig
 
 const (
-	ServerMode RunMode = "server"
-	ClientMode RunMode = "client"
-	AdminMode  RunMode = "admin"
-)
-
-var (
-	mode RunMode
-
-	hostConfig      ExternalHostConfig
-	hostSingleton   sync.Once
-	clientConfig    ExternalClientConfig
-	clientSingleton sync.Once
+	ServerMode = config.ServerMode
+	ClientMode = config.ClientMode
+	AdminMode  = config.AdminMode
 )
 
-func (m *RunMode) String() string {
-	return string(*m)
-}
-
-func (m *RunMode) Set(value string) error {
-	switch strings.ToLower(value) {
-	case "server", "s":
-		*m = ServerMode
-	case "client", "c":
-		*m = ClientMode
-	case "admin", "a":
-		*m = AdminMode
-	default:
-		return fmt.Errorf("Invalid mode: %s (valid options: server, client, admin)", value)
-	}
-	return nil
-}
-
 func GetMode() *RunMode {
-	return &mode
+	return config.GetMode()
 }
 
 func SetMode(m RunMode) {
-	mode = m
+	config.SetMode(m)
 }
 
 func InitHostConfig(cfgName string) ExternalHostConfig {
-	hostSingleton.Do(func() {
-		hostConfig = GetExternalHostConfig(cfgName)
-	})
-
-	return hostConfig
+	return config.InitHostConfig(cfgName)
 }
 
 func GetHostConfig() ExternalHostConfig {
-	return hostConfig
+	return config.GetHostConfig()
 }
 
 func InitClientConfig(cfgName string) ExternalClientConfig {
-	clientSingleton.Do(func() {
-		clientConfig = GetExternalClientConfig(cfgName)
-	})
-
-	return clientConfig
+	return config.InitClientConfig(cfgName)
 }
 
 func GetClientConfig() ExternalClientConfig {
-	return clientConfig
+	return config.GetClientConfig()
 }
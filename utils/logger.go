@@ -1,82 +1,50 @@
 package utils
 
 import (
-	"fmt"
+	"JustSync/pkg"
 	"io"
-	"os"
-	"sync"
-	"time"
 )
 
-type LogLevel int
+// LogLevel is kept as an alias so existing callers of utils.SetLevel don't
+// need to learn about pkg.Level.
+type LogLevel = pkg.Level
 
 const (
-	LevelDebug LogLevel = iota
-	LevelInfo
-	LevelWarn
-	LevelError
+	LevelDebug = pkg.LevelDebug
+	LevelInfo  = pkg.LevelInfo
+	LevelWarn  = pkg.LevelWarn
+	LevelError = pkg.LevelError
 )
 
-var (
-	levelNames = map[LogLevel]string{
-		LevelDebug: "DEBUG",
-		LevelInfo:  "INFO",
-		LevelWarn:  "WARN",
-		LevelError: "ERROR",
-	}
-	logLevel LogLevel
-	lock     sync.Mutex
-	output   io.Writer = os.Stdout
-)
+var log = pkg.NewLogger("utils")
 
+// SetLevel and SetOutput are forwarded to the pkg façade so utils.LogError/
+// utils.LogInfo calls actually honor them, the same as any other subsystem.
 func SetLevel(level LogLevel) {
-	lock.Lock()
-	defer lock.Unlock()
-	logLevel = level
+	pkg.SetLevel(level)
 }
 
 func SetOutput(w io.Writer) {
-	lock.Lock()
-	defer lock.Unlock()
-	output = w
+	pkg.SetOutput(w)
 }
 
-func log(level LogLevel, format string, args ...interface{}) {
-	if level < logLevel {
-		return
-	}
-
-	lock.Lock()
-	defer lock.Unlock()
-
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	levelName := levelNames[level]
-	message := fmt.Sprintf(format, args...)
-
-	logEntry := fmt.Sprintf(
-		"[%s] [%s] [%s] %s\n",
-		timestamp,
-		GetMode().String(),
-		levelName,
-		message,
-	)
-
-	output.Write([]byte(logEntry))
+func withMode() *pkg.Logger {
+	return log.With("mode", GetMode().String())
 }
 
 // Convenience methods
 func LogDebug(format string, args ...interface{}) {
-	log(LevelDebug, format, args...)
+	withMode().Debug(format, args...)
 }
 
 func LogInfo(format string, args ...interface{}) {
-	log(LevelInfo, format, args...)
+	withMode().Info(format, args...)
 }
 
 func LogWarn(format string, args ...interface{}) {
-	log(LevelWarn, format, args...)
+	withMode().Warn(format, args...)
 }
 
 func LogError(format string, args ...interface{}) {
-	log(LevelError, format, args...)
+	withMode().Error(format, args...)
 }
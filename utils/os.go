@@ -1,20 +1,22 @@
 package utils
 
 import (
+	"JustSync/filter"
+	"JustSync/internal/config"
+	"JustSync/service/chunkstore"
 	"JustSync/snapshot"
+	"JustSync/snapshot/chunked"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
-	"time"
 
 	"github.com/restic/chunker"
 	"github.com/zeebo/blake3"
-	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -24,11 +26,28 @@ const (
 	ChunkerPol   = 0x3DA3358B4DC173 // Recommended CDC polynomial
 )
 
-func ProcessDir(root string) (*snapshot.ProjectSnapshot, error) {
+// CreateSnapshotOfDir builds a full ProjectSnapshot of every file under
+// path with no configured exclude/include patterns (only path's own
+// filter.IgnoreFileName applies) - the manual "snapshot this
+// whole directory" entry point HandleCreateSnapshot exposes, as opposed
+// to PrepareInitiateProjectSync's policy-aware walk of an already
+// configured session.
+func CreateSnapshotOfDir(path string) (*snapshot.ProjectSnapshot, error) {
+	match, err := filter.New(path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not load %s: %w", filter.IgnoreFileName, err)
+	}
+	return ProcessDir(path, match, 0)
+}
+
+// ProcessDir walks root and builds a ProjectSnapshot of every file that
+// survives match (see package filter), skipping symlinks and anything
+// larger than maxFileSize (0 means unbounded) rather than chunking them. A
+// directory that match excludes is pruned with filepath.SkipDir instead of
+// being descended into and rejected file-by-file.
+func ProcessDir(root string, match *filter.Matcher, maxFileSize int64) (*snapshot.ProjectSnapshot, error) {
 	snap := &snapshot.ProjectSnapshot{
-		Version:   "1.0",
-		Timestamp: time.Now().UnixNano(),
-		Files:     map[string]*snapshot.FileChunks{},
+		Files: map[string]*snapshot.InitialSyncFile{},
 	}
 
 	if info, err := os.Stat(root); err != nil {
@@ -42,19 +61,56 @@ func ProcessDir(root string) (*snapshot.ProjectSnapshot, error) {
 			return fmt.Errorf("access error at %s: %w", path, err)
 		}
 
-		// Skip directories
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			rel = path
+		}
+
 		if d.IsDir() {
+			if rel != "." && match.Match(rel, true) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		filesnap, e := processFile(path)
+		if match.Match(rel, false) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			LogDebug("Skipping symlink %s", rel)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat error at %s: %w", path, err)
+		}
+		if maxFileSize > 0 && info.Size() > maxFileSize {
+			LogWarn("Skipping %s: %d bytes exceeds the configured max file size of %d", rel, info.Size(), maxFileSize)
+			return nil
+		}
 
+		filesnap, _, e := CreateSnapshotOfFile(path)
 		if e != nil {
 			// Handle but don't abort on file processing errors
-			return fmt.Errorf("processing error: %v\n", err)
+			return fmt.Errorf("processing error: %v\n", e)
 		}
 
-		snap.Files[path] = &filesnap
+		// filesnap.Chunks only carries hash/offset/size (content already
+		// lives in chunkstore, same as everywhere else a ProjectSnapshot
+		// entry is built) - translate straight into the current format.
+		chunks := make([]*snapshot.InitialSyncChunk, len(filesnap.Chunks))
+		for i, c := range filesnap.Chunks {
+			chunks[i] = &snapshot.InitialSyncChunk{
+				Checksum: c.Hash,
+				Offset:   c.Offset,
+				Size:     c.Size,
+			}
+		}
+		snap.Files[path] = &snapshot.InitialSyncFile{
+			Checksum: filesnap.WholeHash,
+			Chunks:   chunks,
+		}
 
 		return nil
 	}); err != nil {
@@ -96,7 +152,63 @@ func processFile(path string) (snapshot.FileChunks, error) {
 	return snap, nil
 }
 
-// ChunkFileContentDefined chunks files using CDC
+// CreateSnapshotOfFile builds path's FileChunks entry the same way
+// processFile does, then additionally lays the chunked content out as a
+// zstd:chunked-style blob (see snapshot/chunked) at ChunkedBlobPath, so a
+// peer cloning the project can range-fetch just the chunks it's missing
+// instead of being pushed the whole file inline.
+func CreateSnapshotOfFile(path string) (*snapshot.FileChunks, *chunked.TOC, error) {
+	filesnap, err := processFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunkData := make([][]byte, len(filesnap.Chunks))
+	hashes := make([][]byte, len(filesnap.Chunks))
+	for i, c := range filesnap.Chunks {
+		data, err := chunkstore.Get(c.Hash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading chunk %x from chunk store: %w", c.Hash, err)
+		}
+		chunkData[i] = data
+		hashes[i] = c.Hash
+	}
+
+	blobPath := ChunkedBlobPath(filesnap.WholeHash)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return nil, nil, err
+	}
+	blob, err := os.Create(blobPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer blob.Close()
+
+	toc, err := chunked.Write(blob, chunkData, hashes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &filesnap, toc, nil
+}
+
+// ChunkedBlobPath is where CreateSnapshotOfFile writes the chunked zstd
+// blob for a file with the given whole-file hash, fanned out under the
+// chunk store root the same way chunkstore itself shards individual
+// chunks - so it shares the store's lifecycle without chunkstore.Prune
+// mistaking it for a stray chunk (Prune only walks hash-named files, and
+// "blobs" isn't one).
+func ChunkedBlobPath(wholeHash []byte) string {
+	name := hex.EncodeToString(wholeHash)
+	if len(name) < 4 {
+		return filepath.Join(chunkstore.Root, "blobs", name+".zst")
+	}
+	return filepath.Join(chunkstore.Root, "blobs", name[:2], name[2:4], name+".zst")
+}
+
+// ChunkFileContentDefined chunks files using CDC, persisting each chunk's
+// bytes into the shared chunkstore keyed by its hash so the in-memory
+// snapshot only needs to carry hash/offset/size - not the content itself.
 func ChunkFileContentDefined(file io.Reader) ([]*snapshot.Chunk, error) {
 	hasher := GetHasher()
 	var chunks []*snapshot.Chunk
@@ -119,6 +231,12 @@ func ChunkFileContentDefined(file io.Reader) ([]*snapshot.Chunk, error) {
 		hash := hasher(c.Data)
 		size := int64(len(c.Data))
 
+		if err := chunkstore.Put(hash, c.Data); err != nil {
+			LogError("Could not persist chunk to chunk store: %s", err.Error())
+			return nil, err
+		}
+		chunkstore.Ref(hash)
+
 		chunk := snapshot.Chunk{
 			Hash:   hash,
 			Offset: offset,
@@ -140,18 +258,23 @@ func GetHasher() func([]byte) []byte {
 	}
 }
 
-func GetOsSpecificConfigPath() string {
-	switch runtime.GOOS {
-	case "windows": // Well... windows
-		return filepath.Join(os.Getenv("APPDATA"), "JustSync")
-	case "darwin": // Macos
-		return filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "JustSync")
-	default: // Linux, BSD, ...
-		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
-			return filepath.Join(xdg, "JustSync")
-		}
-		return filepath.Join(os.Getenv("HOME"), ".config", "JustSync")
+// HashReader hashes r using the same blake3 algorithm as GetHasher,
+// streaming through r instead of requiring its full content in memory at
+// once. Use this over GetHasher for anything that may not comfortably
+// fit in RAM, such as a freshly reconstructed multi-gigabyte file.
+func HashReader(r io.Reader) ([]byte, error) {
+	h := blake3.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
 	}
+	return h.Sum(nil), nil
+}
+
+// GetOsSpecificConfigPath forwards to config.GetOsSpecificConfigPath so
+// every part of JustSync agrees on where configs and the device identity
+// live, instead of keeping a second copy of this switch in sync.
+func GetOsSpecificConfigPath() string {
+	return config.GetOsSpecificConfigPath()
 }
 
 func CreateConfigFolderAt(path string) {
@@ -165,37 +288,3 @@ func CreateConfigFolderAt(path string) {
 		LogInfo("Config directory does already exist")
 	}
 }
-
-func GetExternalClientConfig(name string) ExternalClientConfig {
-	var config ExternalClientConfig
-	path := filepath.Join(GetOsSpecificConfigPath(), name+".yml")
-	configContent, err := os.ReadFile(path)
-	if err != nil {
-		LogError("Config '%s' not found at os' specific config path '%s'", name, path)
-		return config
-	}
-
-	if err = yaml.Unmarshal(configContent, &config); err != nil {
-		LogError("Error in config '%s' found. Could not parse config.", name)
-		return config
-	}
-
-	return config
-}
-
-func GetExternalHostConfig(name string) ExternalHostConfig {
-	var config ExternalHostConfig
-	path := filepath.Join(GetOsSpecificConfigPath(), name+".yml")
-	configContent, err := os.ReadFile(path)
-	if err != nil {
-		LogError("Config '%s' not found at os' specific config path '%s'", name, path)
-		return config
-	}
-
-	if err = yaml.Unmarshal(configContent, &config); err != nil {
-		LogError("Error in config '%s' found. Could not parse config.", name)
-		return config
-	}
-
-	return config
-}
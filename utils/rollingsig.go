@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"JustSync/snapshot"
+	"bytes"
+	"io"
+)
+
+// RollingBlockSize is the fixed block size ComputeSignature divides the
+// basis version into, matching librsync's default. Unlike
+// ChunkFileContentDefined's CDC boundaries, rolling-delta matching relies
+// on trying every byte offset in the new content against the signature, so
+// blocks here are fixed-size rather than content-defined.
+const RollingBlockSize = 2048
+
+// weakChecksum is a rolling Adler-32-style checksum (per Tridgell's rsync
+// algorithm): sum of bytes plus a weighted sum of bytes, each mod 2^16
+// (weakChecksumMod). Unlike the strong hash, it can be recomputed for the
+// next window in O(1) via rollWeakChecksum instead of rehashing the whole
+// window, which is what makes scanning every offset of the new content
+// affordable.
+type weakChecksum struct {
+	a, b uint32
+}
+
+const weakChecksumMod = 1 << 16
+
+func newWeakChecksum(block []byte) weakChecksum {
+	var a, b uint32
+	n := uint32(len(block))
+	for i, c := range block {
+		a += uint32(c)
+		b += (n - uint32(i)) * uint32(c)
+	}
+	return weakChecksum{a: a % weakChecksumMod, b: b % weakChecksumMod}
+}
+
+func (w weakChecksum) value() uint32 {
+	return w.a | (w.b << 16)
+}
+
+// roll advances the window by one byte: out leaves the window at the low
+// end, in enters at the high end, and blockLen is the (fixed) window size.
+func (w weakChecksum) roll(out, in byte, blockLen uint32) weakChecksum {
+	a := (w.a - uint32(out) + uint32(in)) % weakChecksumMod
+	b := (w.b - blockLen*uint32(out) + a) % weakChecksumMod
+	return weakChecksum{a: a, b: b}
+}
+
+// ComputeSignature divides basis into RollingBlockSize-sized blocks
+// (the final block may be shorter) and records each one's weak rolling
+// checksum plus its strong blake3 hash (see GetHasher), the signature a
+// sender matches new content against to find regions that can be copied
+// from the basis instead of sent as literal bytes.
+func ComputeSignature(basis io.Reader) (*snapshot.RollingSignature, error) {
+	hasher := GetHasher()
+	sig := &snapshot.RollingSignature{BlockSize: RollingBlockSize}
+
+	buf := make([]byte, RollingBlockSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(basis, buf)
+		if n > 0 {
+			block := buf[:n]
+			sig.Blocks = append(sig.Blocks, &snapshot.SignatureBlock{
+				WeakHash:   newWeakChecksum(block).value(),
+				StrongHash: hasher(block),
+				Offset:     offset,
+			})
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sig, nil
+}
+
+// ComputeDeltaInstructions diffs newContent against sig using the rsync
+// algorithm: a rolling weak-checksum scan over newContent, confirmed
+// against a signature block's strong hash on a weak-hash collision, turns
+// a matching region into a Copy instruction referencing that block,
+// coalescing everything in between - and after the last match - into
+// Literal instructions. A sender only has to transmit the Literal bytes;
+// everything else, the receiver already holds in its own copy of basis.
+func ComputeDeltaInstructions(newContent []byte, sig *snapshot.RollingSignature) []*snapshot.DeltaInstruction {
+	hasher := GetHasher()
+	blockSize := int(sig.BlockSize)
+	if blockSize <= 0 {
+		blockSize = RollingBlockSize
+	}
+
+	byWeak := make(map[uint32][]*snapshot.SignatureBlock, len(sig.Blocks))
+	for _, b := range sig.Blocks {
+		byWeak[b.WeakHash] = append(byWeak[b.WeakHash], b)
+	}
+
+	var instructions []*snapshot.DeltaInstruction
+	var literal []byte
+
+	flushLiteral := func() {
+		if len(literal) > 0 {
+			instructions = append(instructions, &snapshot.DeltaInstruction{Literal: literal})
+			literal = nil
+		}
+	}
+
+	i := 0
+	var weak weakChecksum
+	haveWeak := false
+	for i < len(newContent) {
+		end := i + blockSize
+		if end > len(newContent) {
+			end = len(newContent)
+		}
+		window := newContent[i:end]
+
+		if !haveWeak || len(window) != blockSize {
+			weak = newWeakChecksum(window)
+			haveWeak = len(window) == blockSize
+		}
+
+		if match := matchBlock(weak.value(), window, byWeak, hasher); match != nil {
+			flushLiteral()
+			instructions = append(instructions, &snapshot.DeltaInstruction{
+				Copy:   true,
+				Offset: match.Offset,
+				Length: int64(len(window)),
+			})
+			i = end
+			haveWeak = false
+			continue
+		}
+
+		literal = append(literal, newContent[i])
+		if haveWeak && i+blockSize < len(newContent) {
+			weak = weak.roll(newContent[i], newContent[i+blockSize], uint32(blockSize))
+		} else {
+			haveWeak = false
+		}
+		i++
+	}
+	flushLiteral()
+
+	return instructions
+}
+
+func matchBlock(weak uint32, window []byte, byWeak map[uint32][]*snapshot.SignatureBlock, hasher func([]byte) []byte) *snapshot.SignatureBlock {
+	candidates, ok := byWeak[weak]
+	if !ok {
+		return nil
+	}
+	strong := hasher(window)
+	for _, c := range candidates {
+		if bytes.Equal(c.StrongHash, strong) {
+			return c
+		}
+	}
+	return nil
+}
+
+// ApplyInstructions reconstructs the full file content instructions
+// describes: a Copy instruction reads Length bytes starting at Offset out
+// of basis, while a non-Copy instruction appends its Literal bytes
+// directly - the inverse of ComputeDeltaInstructions.
+func ApplyInstructions(instructions []*snapshot.DeltaInstruction, basis []byte) ([]byte, error) {
+	var out []byte
+	for _, instr := range instructions {
+		if instr.Copy {
+			end := instr.Offset + instr.Length
+			if instr.Offset < 0 || end > int64(len(basis)) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			out = append(out, basis[instr.Offset:end]...)
+			continue
+		}
+		out = append(out, instr.Literal...)
+	}
+	return out, nil
+}
@@ -3,6 +3,9 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,6 +15,21 @@ const (
 	OtpExpiration   = 10 * time.Minute
 	TokenLength     = 32
 	TokenExpiration = 24 * time.Hour
+
+	// PairingCodeWords is how many words a relay pairing code (see
+	// JustSync/websocket's Relay) strings together, the same tradeoff
+	// croc's --code makes: short enough to read over a phone call, long
+	// enough that guessing one before it expires isn't practical.
+	PairingCodeWords = 3
+
+	// MaxOtpAttempts is how many wrong SPAKE2 confirmations an active OTP
+	// or pairing code tolerates (see RecordOtpFailure) before it's
+	// invalidated outright, same as if it had been consumed - otherwise
+	// an attacker who doesn't know the code gets unlimited guesses
+	// against it for the rest of OtpExpiration, which is a real
+	// dictionary-attack surface against GeneratePairingCode's much
+	// smaller wordlist alphabet.
+	MaxOtpAttempts = 3
 )
 
 var (
@@ -24,13 +42,24 @@ type TokenManager struct {
 	tokens     map[string]time.Time // token -> expiration
 	otpsMutex  sync.RWMutex
 	tokenMutex sync.RWMutex
+	// lastOtp is the most recently issued OTP still in otps, so
+	// CurrentOtp has a single value to hand ServeWs's PAKE exchange -
+	// SPAKE2 needs both sides to agree on the password before either one
+	// speaks, so unlike ValidateOtp's membership check, this can't wait
+	// for the peer to present a candidate first.
+	lastOtp string
+	// otpFailures counts consecutive wrong SPAKE2 confirmations per
+	// still-active OTP (see RecordOtpFailure); guarded by otpsMutex
+	// alongside otps itself, since the two always change together.
+	otpFailures map[string]int
 }
 
 func GetTokenManager() *TokenManager {
 	once.Do(func() {
 		instance = &TokenManager{
-			otps:   make(map[string]time.Time),
-			tokens: make(map[string]time.Time),
+			otps:        make(map[string]time.Time),
+			tokens:      make(map[string]time.Time),
+			otpFailures: make(map[string]int),
 		}
 		go instance.CleanUpReguarly()
 	})
@@ -44,11 +73,40 @@ func (m *TokenManager) GenerateOtp() string {
 
 	m.otpsMutex.Lock()
 	m.otps[otp] = time.Now().Add(OtpExpiration)
+	m.lastOtp = otp
 	m.otpsMutex.Unlock()
 
 	return otp
 }
 
+// CurrentOtp returns the most recently issued OTP, for ServeWs to start a
+// PAKE exchange with, and whether it's still valid (issued and
+// not yet expired). Unlike ValidateOtp, this doesn't consume it - the
+// handshake itself proves whether the joining peer actually knew it;
+// ConsumeOtp does the invalidation once that succeeds.
+func (m *TokenManager) CurrentOtp() (string, bool) {
+	m.otpsMutex.RLock()
+	defer m.otpsMutex.RUnlock()
+
+	if m.lastOtp == "" {
+		return "", false
+	}
+	expiration, ok := m.otps[m.lastOtp]
+	if !ok || time.Now().After(expiration) {
+		return "", false
+	}
+	return m.lastOtp, true
+}
+
+// ConsumeOtp invalidates otp so it can't be reused for a second
+// handshake, mirroring ValidateOtp's one-time-use semantics.
+func (m *TokenManager) ConsumeOtp(otp string) {
+	m.otpsMutex.Lock()
+	defer m.otpsMutex.Unlock()
+	delete(m.otps, otp)
+	delete(m.otpFailures, otp)
+}
+
 func (m *TokenManager) ValidateOtp(otp string) bool {
 	m.otpsMutex.Lock()
 	defer m.otpsMutex.Unlock()
@@ -61,10 +119,72 @@ func (m *TokenManager) ValidateOtp(otp string) bool {
 
 	// Delete otp from memory, therefore invalidating it
 	delete(m.otps, otp)
+	delete(m.otpFailures, otp)
 
 	return time.Now().Before(expiration)
 }
 
+// RecordOtpFailure notes that a SPAKE2 handshake against otp just failed
+// its confirmation check - i.e. the peer guessed wrong - invalidating
+// otp once that's happened MaxOtpAttempts times in a row, the same as a
+// successful ConsumeOtp would. Reports whether this call was the one
+// that locked it out, so the caller (see
+// websocket.runPakeHandshake) can log that a fresh code needs to be
+// issued rather than silently letting the next guess through.
+//
+// A no-op, reporting false, if otp is already gone (consumed or
+// expired) - there's nothing left to lock out.
+func (m *TokenManager) RecordOtpFailure(otp string) bool {
+	m.otpsMutex.Lock()
+	defer m.otpsMutex.Unlock()
+
+	if _, ok := m.otps[otp]; !ok {
+		return false
+	}
+
+	m.otpFailures[otp]++
+	if m.otpFailures[otp] < MaxOtpAttempts {
+		return false
+	}
+
+	delete(m.otps, otp)
+	delete(m.otpFailures, otp)
+	if m.lastOtp == otp {
+		m.lastOtp = ""
+	}
+	return true
+}
+
+// GeneratePairingCode issues a short, human-typable code (e.g.
+// "copper-orbit-falcon") for relay rendezvous, reusing the same otps map
+// and expire-after-N-minutes-or-first-use semantics as GenerateOtp - the
+// code is simply drawn from a smaller, easier-to-read-aloud alphabet.
+// ValidateOtp validates it exactly like any other OTP.
+func (m *TokenManager) GeneratePairingCode() string {
+	words := make([]string, PairingCodeWords)
+	for i := range words {
+		words[i] = pairingWord()
+	}
+	code := strings.Join(words, "-")
+
+	m.otpsMutex.Lock()
+	m.otps[code] = time.Now().Add(OtpExpiration)
+	m.otpsMutex.Unlock()
+
+	return code
+}
+
+func pairingWord() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(pairingWordlist))))
+	if err != nil {
+		// crypto/rand failing is not something we can recover from
+		// meaningfully here; panicking matches the rest of this file's
+		// reliance on crypto/rand always succeeding.
+		panic(fmt.Sprintf("utils: could not generate pairing word: %s", err.Error()))
+	}
+	return pairingWordlist[n.Int64()]
+}
+
 func (m *TokenManager) GenerateToken() string {
 	b := make([]byte, TokenLength)
 	rand.Read(b)
@@ -102,6 +222,7 @@ func (m *TokenManager) CleanUpReguarly() {
 		for otp, exp := range m.otps {
 			if now.After(exp) {
 				delete(m.otps, otp)
+				delete(m.otpFailures, otp)
 			}
 		}
 		m.otpsMutex.Unlock()
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordOtpFailureLocksOutAfterMaxAttempts(t *testing.T) {
+	m := &TokenManager{
+		otps:        make(map[string]time.Time),
+		otpFailures: make(map[string]int),
+	}
+	otp := "guess-me"
+	m.otps[otp] = time.Now().Add(OtpExpiration)
+
+	for i := 1; i < MaxOtpAttempts; i++ {
+		if m.RecordOtpFailure(otp) {
+			t.Fatalf("RecordOtpFailure locked out after %d attempts, want %d", i, MaxOtpAttempts)
+		}
+	}
+
+	if !m.RecordOtpFailure(otp) {
+		t.Fatalf("RecordOtpFailure did not lock out after %d attempts", MaxOtpAttempts)
+	}
+
+	if _, ok := m.otps[otp]; ok {
+		t.Errorf("otp still present in otps after lockout")
+	}
+	if _, ok := m.otpFailures[otp]; ok {
+		t.Errorf("otpFailures entry still present after lockout")
+	}
+}
+
+func TestRecordOtpFailureNoopOnceConsumed(t *testing.T) {
+	m := &TokenManager{
+		otps:        make(map[string]time.Time),
+		otpFailures: make(map[string]int),
+	}
+	otp := "already-gone"
+
+	if m.RecordOtpFailure(otp) {
+		t.Fatalf("RecordOtpFailure reported lockout for an otp that was never active")
+	}
+}
+
+func TestConsumeOtpClearsFailures(t *testing.T) {
+	m := &TokenManager{
+		otps:        make(map[string]time.Time),
+		otpFailures: make(map[string]int),
+	}
+	otp := "one-wrong-guess"
+	m.otps[otp] = time.Now().Add(OtpExpiration)
+	m.RecordOtpFailure(otp)
+
+	m.ConsumeOtp(otp)
+	if _, ok := m.otpFailures[otp]; ok {
+		t.Errorf("otpFailures entry survived ConsumeOtp")
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+// pairingWordlist is the alphabet GeneratePairingCode draws from: short,
+// unambiguous-to-say-aloud English words with no near-homophones in the
+// list, so a code read over a phone call or dictated by a teammate
+// transcribes back unambiguously.
+var pairingWordlist = []string{
+	"amber", "anchor", "apple", "arrow", "autumn",
+	"banjo", "barrel", "basil", "beacon", "birch",
+	"canyon", "cedar", "cello", "cinder", "clover",
+	"comet", "copper", "coral", "cosmic", "crimson",
+	"delta", "dover", "dragon", "drizzle", "dune",
+	"ember", "falcon", "feather", "fennel", "fjord",
+	"forest", "galaxy", "garnet", "glacier", "granite",
+	"harbor", "hazel", "heron", "hollow", "indigo",
+	"island", "ivory", "jasper", "juniper", "kestrel",
+	"lagoon", "lantern", "lumen", "maple", "marble",
+	"meadow", "mirage", "nectar", "nimbus", "nutmeg",
+	"oasis", "onyx", "opal", "orbit", "orchid",
+	"otter", "pebble", "pepper", "pewter", "pine",
+	"prairie", "quartz", "quill", "raven", "ridge",
+	"river", "rocket", "saffron", "sage", "sequoia",
+	"shadow", "sierra", "silver", "sparrow", "spruce",
+	"sunset", "tangerine", "thistle", "thunder", "timber",
+	"topaz", "tundra", "velvet", "violet", "walnut",
+	"willow", "winter", "zephyr", "zinc",
+}
@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"JustSync/service"
+	"JustSync/snapshot"
+	"JustSync/utils"
+	"time"
+)
+
+// AckInterval is how often a peer reports the highest contiguous sequence
+// number it has received back to the host, so the host's OutboundQueue can
+// drop messages it no longer needs to keep around for a replay.
+const AckInterval = 2 * time.Second
+
+// StartAckLoop periodically sends an Ack for whatever service.LastAckedSeq
+// has advanced to, until stop is closed. It only sends when the value has
+// changed since the last tick, so a quiet connection doesn't spam acks.
+// Callers should close stop (and start a fresh loop) whenever the host
+// connection is re-established, since a reconnect gets its own
+// ResumeRequest to report the same information.
+func StartAckLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(AckInterval)
+	defer ticker.Stop()
+
+	var lastSent uint64
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			seq := service.LastAckedSeq()
+			if seq == lastSent {
+				continue
+			}
+
+			msg := &snapshot.WebsocketMessage{
+				Payload: &snapshot.WebsocketMessage_Ack{
+					Ack: &snapshot.Ack{HighestContiguousSeq: seq},
+				},
+			}
+			if err := SendToHost(msg); err != nil {
+				utils.LogError("Could not send ack: %s", err.Error())
+				continue
+			}
+			lastSent = seq
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay before a reconnect attempt: exponential up to
+// Max, with +/-Jitter fractional randomness so a host restart doesn't get
+// hammered by every peer reconnecting in lockstep.
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+// DefaultBackoff is used by the reconnect loop in cmd/main.
+var DefaultBackoff = Backoff{
+	Base:   500 * time.Millisecond,
+	Max:    30 * time.Second,
+	Jitter: 0.2,
+}
+
+// Next returns the delay to wait before reconnect attempt number attempt
+// (0-indexed: the first retry after an initial failure is attempt 0).
+func (b Backoff) Next(attempt int) time.Duration {
+	delay := b.Base
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= b.Max {
+			delay = b.Max
+			break
+		}
+	}
+
+	jitter := 1 + b.Jitter*(2*rand.Float64()-1)
+	delay = time.Duration(float64(delay) * jitter)
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
@@ -2,9 +2,13 @@ package websocket
 
 import (
 	"JustSync/service"
+	"JustSync/service/ratelimit"
+	"JustSync/snapshot"
 	"JustSync/utils"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,7 +16,7 @@ import (
 )
 
 var (
-	hostConn *websocket.Conn
+	hostConn Conn
 )
 
 const (
@@ -27,17 +31,64 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 )
 
+// Conn is the subset of *websocket.Conn that Client and the host
+// connection need, satisfied by a direct *websocket.Conn or by an
+// *EncryptedConn paired through a Relay - so a relay-paired session is
+// handled identically to a direct one everywhere a connection is
+// consumed.
+type Conn interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(string) error)
+	EnableWriteCompression(enable bool)
+	SetCompressionLevel(level int) error
+}
+
 type Client struct {
 	hub  *Hub
-	Conn *websocket.Conn
+	Conn Conn
+	// send is never closed - readPump's handleChunkRequest,
+	// DoFullProjectSync and Hub's deliverLoop all write to it directly,
+	// and closing it out from under any one of them would panic the
+	// writer. writePump instead stops on done, same as deliverLoop; every
+	// other writer goes through trySend, which gives up on done too
+	// instead of blocking forever once writePump has stopped draining.
 	send chan []byte
+	// pending is an unbounded FIFO of broadcasts awaiting delivery to
+	// this client, fed by Hub.Run and drained in order by deliverLoop -
+	// see pendingQueue for why it isn't just a buffered channel.
+	pending *pendingQueue
+	// done is closed once by Hub's unregister handling, signaling both
+	// deliverLoop and writePump to stop.
+	done chan struct{}
+	// outbox tracks every message sent to this peer that hasn't been
+	// acked yet, keyed by its ResumeRequest's session ID, so a
+	// reconnecting peer can be replayed instead of re-synced from
+	// scratch. It's nil until readResumeRequest succeeds.
+	outbox *service.OutboundQueue
+	// id identifies this connection for service.Events' PeerRegistered/
+	// PeerUnregistered events, and for Bandwidth's accounting - assigned
+	// at construction, since the session ID outbox is eventually keyed by
+	// isn't known until after the handshake has already registered the
+	// client.
+	id string
+	// readLimiter and writeLimiter meter this connection's plaintext
+	// bytes independently in each direction, so one Client's
+	// DoFullProjectSync can't starve every other peer's own writePump -
+	// Hub.Run's broadcast loop has no other notion of fairness between
+	// clients. Both are always non-nil; a zero-valued ratelimit.Bucket
+	// (RateLimitBytesPerSec unset) just never blocks.
+	readLimiter, writeLimiter *ratelimit.Bucket
 }
 
-func SetHostConnection(conn *websocket.Conn) {
+func SetHostConnection(conn Conn) {
 	hostConn = conn
 }
 
-func GetHostConnection() *websocket.Conn {
+func GetHostConnection() Conn {
 	return hostConn
 }
 
@@ -57,11 +108,92 @@ func (c *Client) readPump() {
 		}
 		utils.LogInfo("Message received")
 
+		c.readLimiter.Wait(len(msg))
+		Bandwidth.RecordIn(c.id, payloadTypeName(msg), len(msg))
+
+		if c.handleAck(msg) {
+			continue
+		}
+
+		if c.handleChunkRequest(msg) {
+			continue
+		}
+
 		c.hub.Broadcast <- msg
 	}
 }
 
+// handleAck checks whether raw is an Ack message and, if so, applies it to
+// c.outbox and reports true so the caller skips broadcasting it as sync
+// data - an Ack is plumbing between a peer and this Client, not a message
+// for the rest of the hub's clients to receive.
+func (c *Client) handleAck(raw []byte) bool {
+	var msg snapshot.WebsocketMessage
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		return false
+	}
+
+	ack, ok := msg.Payload.(*snapshot.WebsocketMessage_Ack)
+	if !ok {
+		return false
+	}
+
+	if c.outbox != nil {
+		c.outbox.Ack(ack.Ack.HighestContiguousSeq)
+	}
+	return true
+}
+
+// handleChunkRequest checks whether raw is a ChunkRequest and, if so,
+// answers it via service.AnswerChunkRequest and reports true so the
+// caller skips broadcasting it - a ChunkRequest is this peer asking for
+// specific chunk content it's missing from DoFullProjectSync's manifest,
+// not sync data for the rest of the hub.
+func (c *Client) handleChunkRequest(raw []byte) bool {
+	var msg snapshot.WebsocketMessage
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		return false
+	}
+
+	req, ok := msg.Payload.(*snapshot.WebsocketMessage_ChunkRequest)
+	if !ok {
+		return false
+	}
+
+	reply, err := service.AnswerChunkRequest(req.ChunkRequest)
+	if err != nil {
+		utils.LogError("Could not answer chunk request for %s: %s", req.ChunkRequest.Path, err.Error())
+		return true
+	}
+
+	c.outbox.Stamp(reply)
+	content, err := proto.Marshal(reply)
+	if err != nil {
+		utils.LogError("Could not marshal chunk response for %s: %s", req.ChunkRequest.Path, err.Error())
+		return true
+	}
+	c.trySend(content)
+	return true
+}
+
+// trySend writes message to c.send, giving up once done fires instead of
+// blocking forever - writePump stops draining c.send the moment done is
+// closed, so a writer that's still running past that point (readPump's
+// handleChunkRequest, or a DoFullProjectSync still replaying the outbox)
+// would otherwise hang on a send nothing is left to receive. It reports
+// whether the message was actually handed to writePump.
+func (c *Client) trySend(message []byte) bool {
+	select {
+	case c.send <- message:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
 func (c *Client) writePump() {
+	c.Conn.SetCompressionLevel(compressionLevel)
+
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
@@ -70,20 +202,29 @@ func (c *Client) writePump() {
 
 	for {
 		select {
-		case msg, ok := <-c.send:
+		case msg := <-c.send:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				if err := c.Conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
-					utils.LogError("Error writing close message to peer.")
-				}
-				return
-			}
+			c.writeLimiter.Wait(len(msg))
+			Bandwidth.RecordOut(c.id, payloadTypeName(msg), len(msg))
+
+			// Only worth deflating a message past compressionThreshold -
+			// anything smaller already fits comfortably in a frame, and a
+			// payload that's already zstd-compressed (see utils/compress)
+			// won't shrink further either way, so the size check alone is
+			// enough of a proxy without inspecting the payload itself.
+			c.Conn.EnableWriteCompression(len(msg) >= compressionThreshold)
 			c.Conn.WriteMessage(websocket.TextMessage, msg)
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+		case <-c.done:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+				utils.LogError("Error writing close message to peer.")
+			}
+			return
 		}
 	}
 }
@@ -95,62 +236,107 @@ func (c *Client) handleConnectionPreparation() {
 		}
 	}()
 
-	if err := c.ExecuteHandshake(); err != nil {
+	// The PAKE handshake that produced c.Conn (see ServeWs/ServeRelayHost)
+	// already proved the other side knew the active pairing code, so
+	// there's nothing left to check before registering c.
+	c.hub.register <- c
+
+	resume, err := c.readResumeRequest()
+	if err != nil {
+		utils.LogError("Could not read resume request: %s", err.Error())
 		c.Conn.Close()
 		return
 	}
-	c.DoFullProjectSync()
+	c.outbox = service.NewOutboundQueue(resume.SessionId)
+
+	c.DoFullProjectSync(resume)
 	c.readPump()
 }
 
-func (c *Client) ExecuteHandshake() error {
+// readResumeRequest reads the ResumeRequest every peer sends right after
+// the auth handshake, naming the chunks it already holds so DoFullProjectSync
+// can skip re-sending their content. A fresh `peer join` sends one with an
+// empty HaveChunks, so a first sync and a resumed one share this same path.
+func (c *Client) readResumeRequest() (*snapshot.ResumeRequest, error) {
 	c.Conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	defer c.Conn.SetReadDeadline(time.Time{})
 
-	msgType, msg, err := c.Conn.ReadMessage()
-
+	_, raw, err := c.Conn.ReadMessage()
 	if err != nil {
-		utils.LogError("Handshake failed: Could not read auth token")
-		return err
+		return nil, err
 	}
 
-	if msgType != websocket.TextMessage {
-		utils.LogError("Handshake failed: Auth token must be a text message")
-		return fmt.Errorf("Handshake failed: Auth token must be a text message")
+	var msg snapshot.WebsocketMessage
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		return nil, err
 	}
 
-	token := string(msg)
-	if !utils.GetTokenManager().ValidateOtp(token) {
-		utils.LogError("Handshake failed: Invalid auth token received")
-		return fmt.Errorf("Handshake failed: Invalid auth token received")
+	resume, ok := msg.Payload.(*snapshot.WebsocketMessage_ResumeRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a ResumeRequest, got %T", msg.Payload)
 	}
+	return resume.ResumeRequest, nil
+}
 
-	utils.LogInfo("Handshake successful")
-
-	c.Conn.SetReadDeadline(time.Time{})
-	c.hub.register <- c
+// DoFullProjectSync sends a single ProjectManifest listing every project
+// file's ordered chunk hashes, rather than pushing each file's full
+// content up front: the peer pulls back only the chunks its own
+// chunkstore doesn't already have via ChunkRequest (see
+// handleChunkRequest), so c.send never has to carry more than one
+// connection's worth of sync traffic at a time regardless of project
+// size - pushing full content here is exactly what used to blow past
+// c.send's 256-slot buffer on a large project.
+func (c *Client) DoFullProjectSync(resume *snapshot.ResumeRequest) error {
+	start := time.Now()
+	service.Events.Publish(service.Event{Type: service.EventSyncStarted, PeerId: c.id})
 
-	return nil
-}
+	for _, msg := range c.outbox.Replay(resume.LastAckedSeq) {
+		content, err := proto.Marshal(msg)
+		if err != nil {
+			utils.LogError("Unexpected error: could not marshall replayed message.")
+			return err
+		}
+		if !c.trySend(content) {
+			return fmt.Errorf("client disconnected while replaying outbox")
+		}
+	}
 
-func (c *Client) DoFullProjectSync() error {
-	msgs, err := service.PrepareInitiateProjectSync()
+	manifest, err := service.BuildProjectManifest()
 	if err != nil {
-		utils.LogError("Failed to initiate project sync to client due to: %s", err.Error())
+		utils.LogError("Failed to build project manifest for client due to: %s", err.Error())
 		return err
 	}
 
-	for _, msg := range msgs {
-		content, err := proto.Marshal(&msg)
-		if err != nil {
-			utils.LogError("Unexpected error: could not marshall message.")
-			return err
-		}
-		c.send <- content
+	msg := snapshot.WebsocketMessage{
+		Payload: &snapshot.WebsocketMessage_ProjectManifest{ProjectManifest: manifest},
+	}
+	c.outbox.Stamp(&msg)
+	content, err := proto.Marshal(&msg)
+	if err != nil {
+		utils.LogError("Unexpected error: could not marshall message.")
+		return err
 	}
+	if !c.trySend(content) {
+		return fmt.Errorf("client disconnected before the manifest could be sent")
+	}
+
+	service.Events.Publish(service.Event{
+		Type:     service.EventSyncFinished,
+		PeerId:   c.id,
+		Bytes:    int64(len(content)),
+		Duration: time.Since(start),
+	})
 
 	return nil
 }
 
+// ServeWs upgrades a direct /connect request and runs the host side of a
+// PAKE exchange (see pkg/pake) against the admin's currently active
+// pairing code (utils.GetTokenManager().CurrentOtp()) instead of reading
+// a bearer token off the wire - the code itself never crosses the
+// connection, only the A/B shares it blinds and a confirmation tag
+// derived from the session both sides land on, exactly like a
+// relay-paired Client (see ServeRelayHost).
 func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	conn, err := Upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -158,9 +344,162 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := &Client{hub: hub, Conn: conn, send: make(chan []byte, 256)}
+	id := service.NewPeerID()
+
+	code, ok := utils.GetTokenManager().CurrentOtp()
+	if !ok {
+		utils.LogError("Handshake failed: no active pairing code")
+		service.Events.Publish(service.Event{Type: service.EventHandshakeFailed, PeerId: id, Err: "no active pairing code"})
+		conn.Close()
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	encConn, err := DialHostPake(conn, code)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		utils.LogError("Handshake failed: %s", err.Error())
+		service.Events.Publish(service.Event{Type: service.EventHandshakeFailed, PeerId: id, Err: err.Error()})
+		conn.Close()
+		return
+	}
+	utils.GetTokenManager().ConsumeOtp(code)
+	utils.LogInfo("Handshake successful")
+
+	client := newClient(hub, encConn, id)
 
 	// Start read and write pumps
 	go client.writePump()
 	go client.handleConnectionPreparation()
 }
+
+// newClient builds a Client around conn, wiring up its rate limiter and
+// bandwidth accounting from the host's configured RateLimitBytesPerSec.
+func newClient(hub *Hub, conn Conn, id string) *Client {
+	limit := utils.GetHostConfig().Application.RateLimitBytesPerSec
+	Bandwidth.Register(id)
+	return &Client{
+		hub:          hub,
+		Conn:         conn,
+		send:         make(chan []byte, 256),
+		pending:      newPendingQueue(),
+		done:         make(chan struct{}),
+		id:           id,
+		readLimiter:  ratelimit.NewBucket(limit),
+		writeLimiter: ratelimit.NewBucket(limit),
+	}
+}
+
+// pendingQueue is an unbounded FIFO of broadcast messages awaiting
+// delivery to one Client, fed by Hub.Run and drained in order by
+// deliverLoop. It's a slice behind a mutex rather than a fixed-size
+// channel so Hub.Run's fan-out to every client can never block on one
+// slow client, and - unlike falling back to a goroutine-per-message once
+// a bounded channel fills up - there's no capacity to overflow in the
+// first place, so messages to the same client are always enqueued, and
+// therefore delivered, in the order Run fanned them out in.
+type pendingQueue struct {
+	mu     sync.Mutex
+	items  [][]byte
+	notify chan struct{}
+}
+
+func newPendingQueue() *pendingQueue {
+	return &pendingQueue{notify: make(chan struct{}, 1)}
+}
+
+// push appends message and wakes deliverLoop if it's waiting on notify.
+func (q *pendingQueue) push(message []byte) {
+	q.mu.Lock()
+	q.items = append(q.items, message)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes and returns the oldest queued message, if any.
+func (q *pendingQueue) pop() ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	message := q.items[0]
+	q.items = q.items[1:]
+	return message, true
+}
+
+// ServeRelayHost registers code with the relay at relayUrl, waits for a
+// client to dial in under it, runs the host side of the PAKE handshake
+// over the resulting tunnel, and - once that succeeds - hands the
+// connection to hub exactly like ServeWs does for a direct /connect
+// request, so a relay-paired peer is served identically to a direct one.
+// It blocks until a peer pairs (or pairWaitTimeout elapses), so callers
+// that want to keep minting codes after each pairing should call it in a
+// loop.
+func ServeRelayHost(hub *Hub, relayUrl, code string) error {
+	conn, _, err := websocket.DefaultDialer.Dial(relayUrl, nil)
+	if err != nil {
+		return fmt.Errorf("relay: could not dial %s: %w", relayUrl, err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(code)); err != nil {
+		conn.Close()
+		return fmt.Errorf("relay: could not register pairing code: %w", err)
+	}
+
+	encConn, err := DialHostPake(conn, code)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	client := newClient(hub, encConn, service.NewPeerID())
+
+	go client.writePump()
+	client.handleConnectionPreparation()
+	return nil
+}
+
+// DialRelayClient dials relayUrl, joins under code, and runs the client
+// side of the PAKE handshake over the resulting tunnel, returning a Conn
+// that callers consume exactly like a direct *websocket.Conn from
+// gorillaws.DefaultDialer.Dial.
+func DialRelayClient(relayUrl, code string) (Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(relayUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("relay: could not dial %s: %w", relayUrl, err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(code)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("relay: could not send pairing code: %w", err)
+	}
+
+	encConn, err := DialClientPake(conn, code)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return encConn, nil
+}
+
+// payloadTypeName names the WebsocketMessage payload carried in raw, for
+// Bandwidth's per-type counters - e.g. "FileDelta" rather than the Go
+// type name "*snapshot.WebsocketMessage_FileDelta". A frame that fails
+// to unmarshal (shouldn't happen; the caller already parsed or is about
+// to) is counted as "unknown" rather than dropped from accounting
+// entirely.
+func payloadTypeName(raw []byte) string {
+	var msg snapshot.WebsocketMessage
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		return "unknown"
+	}
+	name := fmt.Sprintf("%T", msg.Payload)
+	return strings.TrimPrefix(name, "*snapshot.WebsocketMessage_")
+}
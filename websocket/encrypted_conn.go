@@ -0,0 +1,168 @@
+package websocket
+
+import (
+	"JustSync/pkg/pake"
+	"JustSync/utils"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// EncryptedConn is a websocket connection with every frame additionally
+// sealed under a Session derived from a SPAKE2 handshake (see pkg/pake),
+// so a Relay piping bytes between two NAT-ed peers never sees plaintext -
+// only the host and client, who alone were given the pairing code the
+// handshake ran over, can read what crosses it. It implements the same
+// Conn surface a direct *websocket.Conn does, so callers on either side
+// of a pairing use it exactly like a direct connection once established.
+type EncryptedConn struct {
+	conn    *websocket.Conn
+	session *pake.Session
+}
+
+// DialHostPake runs the host side (RoleServer) of a SPAKE2 handshake over
+// conn - a direct /connect connection, or one already registered with a
+// Relay under code - deriving a session from code as the shared password.
+func DialHostPake(conn *websocket.Conn, code string) (*EncryptedConn, error) {
+	return runPakeHandshake(conn, pake.RoleServer, code)
+}
+
+// DialClientPake runs the client side (RoleClient) of a SPAKE2 handshake
+// over conn - a direct dial to the host's /connect, or one already dialed
+// into a Relay under code - deriving a session from code as the shared
+// password.
+func DialClientPake(conn *websocket.Conn, code string) (*EncryptedConn, error) {
+	return runPakeHandshake(conn, pake.RoleClient, code)
+}
+
+// runPakeHandshake's server side reads the client's share before
+// sending its own, so the client side does the mirror image - send
+// first, then read - and both land on the same clientShare||serverShare
+// transcript regardless of which side is deriving it.
+//
+// On the server side, a wrong confirmation tag counts against code via
+// TokenManager.RecordOtpFailure, so a peer that doesn't actually know
+// the pairing code can't just keep reconnecting and guessing for as long
+// as it stays active.
+func runPakeHandshake(conn *websocket.Conn, role pake.Role, code string) (*EncryptedConn, error) {
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	defer conn.SetReadDeadline(time.Time{})
+
+	state, err := pake.New(role, []byte(code))
+	if err != nil {
+		return nil, fmt.Errorf("pake: could not start handshake: %w", err)
+	}
+
+	var clientShare, serverShare []byte
+
+	if role == pake.RoleServer {
+		serverShare = state.Share()
+
+		_, share, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("pake: could not read client share: %w", err)
+		}
+		clientShare = share
+
+		if err := conn.WriteMessage(websocket.BinaryMessage, serverShare); err != nil {
+			return nil, fmt.Errorf("pake: could not send server share: %w", err)
+		}
+	} else {
+		clientShare = state.Share()
+
+		if err := conn.WriteMessage(websocket.BinaryMessage, clientShare); err != nil {
+			return nil, fmt.Errorf("pake: could not send client share: %w", err)
+		}
+
+		_, share, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("pake: could not read server share: %w", err)
+		}
+		serverShare = share
+	}
+
+	peerShare := serverShare
+	if role == pake.RoleServer {
+		peerShare = clientShare
+	}
+	shared, err := state.Finish(peerShare)
+	if err != nil {
+		return nil, fmt.Errorf("pake: handshake failed, wrong pairing code?: %w", err)
+	}
+
+	transcript := append(append([]byte{}, clientShare...), serverShare...)
+	session, err := pake.NewSession(role, shared, transcript)
+	if err != nil {
+		return nil, fmt.Errorf("pake: could not derive session keys: %w", err)
+	}
+
+	if role == pake.RoleServer {
+		_, confirmTag, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("pake: could not read client confirmation: %w", err)
+		}
+		if subtle.ConstantTimeCompare(confirmTag, session.ConfirmClient()) != 1 {
+			if utils.GetTokenManager().RecordOtpFailure(code) {
+				return nil, fmt.Errorf("pake: client confirmation did not match %d times, pairing code invalidated", utils.MaxOtpAttempts)
+			}
+			return nil, fmt.Errorf("pake: client confirmation did not match, wrong pairing code")
+		}
+	} else {
+		if err := conn.WriteMessage(websocket.BinaryMessage, session.ConfirmClient()); err != nil {
+			return nil, fmt.Errorf("pake: could not send confirmation: %w", err)
+		}
+	}
+
+	utils.LogInfo("Relay: PAKE handshake successful, switching to encrypted framing")
+	return &EncryptedConn{conn: conn, session: session}, nil
+}
+
+func (e *EncryptedConn) ReadMessage() (int, []byte, error) {
+	_, ciphertext, err := e.conn.ReadMessage()
+	if err != nil {
+		return 0, nil, err
+	}
+	plaintext, err := e.session.Open(ciphertext)
+	if err != nil {
+		return 0, nil, fmt.Errorf("relay: could not decrypt frame: %w", err)
+	}
+	return websocket.BinaryMessage, plaintext, nil
+}
+
+func (e *EncryptedConn) WriteMessage(messageType int, data []byte) error {
+	ciphertext, err := e.session.Seal(data)
+	if err != nil {
+		return err
+	}
+	return e.conn.WriteMessage(websocket.BinaryMessage, ciphertext)
+}
+
+func (e *EncryptedConn) Close() error {
+	return e.conn.Close()
+}
+
+// SetReadDeadline, SetWriteDeadline and SetPongHandler pass straight
+// through to the underlying connection: liveness (pings, deadlines) is a
+// concern of the relay hop itself, independent of the encrypted framing
+// layered on top of it.
+func (e *EncryptedConn) SetReadDeadline(t time.Time) error {
+	return e.conn.SetReadDeadline(t)
+}
+
+func (e *EncryptedConn) SetWriteDeadline(t time.Time) error {
+	return e.conn.SetWriteDeadline(t)
+}
+
+func (e *EncryptedConn) SetPongHandler(h func(string) error) {
+	e.conn.SetPongHandler(h)
+}
+
+// EnableWriteCompression and SetCompressionLevel are no-ops here: every
+// frame this connection writes is already sealed ciphertext, which is
+// high-entropy and doesn't compress, so there's nothing for
+// permessage-deflate to usefully do on this path.
+func (e *EncryptedConn) EnableWriteCompression(enable bool) {}
+
+func (e *EncryptedConn) SetCompressionLevel(level int) error { return nil }
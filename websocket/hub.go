@@ -1,15 +1,24 @@
 package websocket
 
 import (
+	"JustSync/service"
+	"JustSync/service/ratelimit"
 	"JustSync/utils"
+	"compress/flate"
 	"net/http"
 	"slices"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Bandwidth is the process-wide bandwidth account every Client's
+// readPump/writePump reports into, and what api.HandleBandwidthMetrics
+// reads back out for the /admin/bandwidth endpoint.
+var Bandwidth = ratelimit.NewRegistry()
+
 var (
 	Upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -17,11 +26,50 @@ var (
 
 		CheckOrigin: CheckOrigin,
 	}
+	// Dialer is what cmd/main's dialHost dials a direct /connect with,
+	// so the compression toggle ConfigureCompression sets applies
+	// symmetrically to both sides of that connection. The relay dials in
+	// ServeRelayHost and DialRelayClient use websocket.DefaultDialer.
+	Dialer         = websocket.DefaultDialer
 	instance       *Hub
 	once           sync.Once
 	allowedOrigins = []string{"sync.fabianholler.live"}
+
+	// compressionLevel is the deflate level applied to outgoing sync
+	// messages once ConfigureCompression turns compression on. Defaults
+	// to flate's own default (0 is not a valid flate level, so this
+	// can't just be the zero value).
+	compressionLevel = flate.DefaultCompression
 )
 
+// compressionThreshold is the minimum plaintext size, in bytes, worth
+// deflating before a write - below it the permessage-deflate framing
+// overhead outweighs any savings, so writePump leaves per-message
+// compression off for anything smaller.
+const compressionThreshold = 1024
+
+// broadcastSendTimeout is how long Hub.Run's broadcast back-pressures
+// against one client's send buffer before giving up on it - long enough
+// that a momentary backlog (e.g. one slow writePump.WriteMessage call)
+// drains on its own instead of losing the peer, short enough that one
+// genuinely stuck client doesn't pin its deliverLoop forever.
+const broadcastSendTimeout = writeWait
+
+// ConfigureCompression turns on permessage-deflate for every connection
+// this package upgrades or dials from here on, so it must be called
+// before GetHub()'s first ServeWs/ServeRelayHost or dialHost call. level
+// is a flate compression level (1-9); zero keeps flate's own default.
+func ConfigureCompression(enabled bool, level int) {
+	Upgrader.EnableCompression = enabled
+	dialer := *Dialer
+	dialer.EnableCompression = enabled
+	Dialer = &dialer
+
+	if level != 0 {
+		compressionLevel = level
+	}
+}
+
 func CheckOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 
@@ -81,35 +129,100 @@ func (h *Hub) Run() {
 			h.Clients[client] = true
 			utils.LogInfo("Registered client %s", strconv.Itoa(len(h.Clients)))
 			h.mu.Unlock()
+			go h.deliverLoop(client)
+			service.Events.Publish(service.Event{Type: service.EventPeerRegistered, PeerId: client.id})
 
 		// Unregister client
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.Clients[client]; ok {
+			_, ok := h.Clients[client]
+			if ok {
 				delete(h.Clients, client)
-				close(client.send)
+			}
+			h.mu.Unlock()
+
+			if ok {
+				// done alone stops both deliverLoop and writePump; send
+				// itself is never closed, so a direct writer on the
+				// other side of a still-in-flight send (handleChunkRequest,
+				// DoFullProjectSync) can never race a close of its own
+				// channel.
+				close(client.done)
 				utils.LogInfo("Unregistered client")
 			} else {
 				utils.LogError("Error while unregistering client")
 			}
-			h.mu.Unlock()
+			Bandwidth.Unregister(client.id)
+			service.Events.Publish(service.Event{Type: service.EventPeerUnregistered, PeerId: client.id})
 
 		// Message received, broadcast it to all clients
 		case message := <-h.Broadcast:
 			utils.LogInfo("Broadcasting message")
-			h.mu.Lock()
+			h.mu.RLock()
+			clients := make([]*Client, 0, len(h.Clients))
 			for client := range h.Clients {
-				select {
-				case client.send <- message:
-					utils.LogInfo("Message broadcasted: %s", message)
-				default:
-					// Fall back. Close and disconnect everything in case the client's send buffer is full or it is dead or stuck
-					utils.LogError("Broadcast failed - maybe the buffer of one of the clients is full or it is dead or stuck")
-					close(client.send)
-					delete(h.Clients, client)
-				}
+				clients = append(clients, client)
+			}
+			h.mu.RUnlock()
+
+			for _, client := range clients {
+				// pending is unbounded, so this never blocks and never
+				// needs a goroutine-spawning overflow path - every
+				// client's messages are pushed, and therefore delivered,
+				// in exactly the order Broadcast received them.
+				client.pending.push(message)
 			}
-			h.mu.Unlock()
 		}
 	}
 }
+
+// deliverLoop is the only goroutine that writes broadcasts to client.send,
+// so broadcasts reach it strictly in the order Run fanned them out in.
+// client.send is never closed, so deliverLoop's sends to it can never
+// race a close - it just stops, along with writePump, once client.done
+// is closed.
+func (h *Hub) deliverLoop(client *Client) {
+	for {
+		message, ok := client.pending.pop()
+		if !ok {
+			select {
+			case <-client.pending.notify:
+				continue
+			case <-client.done:
+				return
+			}
+		}
+		if !h.sendWithBackpressure(client, message) {
+			return
+		}
+	}
+}
+
+// sendWithBackpressure delivers message to client, back-pressuring
+// against a full client.send for up to broadcastSendTimeout before
+// giving up on a stuck client, instead of the old behavior of dropping
+// the peer the instant one broadcast found its buffer momentarily full.
+// It reports whether client is still live; deliverLoop stops once it
+// isn't, whether because this very timeout just unregistered it or
+// because it was disconnected some other way while this was waiting.
+func (h *Hub) sendWithBackpressure(client *Client, message []byte) bool {
+	select {
+	case client.send <- message:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(broadcastSendTimeout)
+	defer timer.Stop()
+
+	select {
+	case client.send <- message:
+		return true
+	case <-timer.C:
+		utils.LogError("Broadcast to a client timed out after %s, disconnecting it", broadcastSendTimeout)
+		h.unregister <- client
+		return false
+	case <-client.done:
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"JustSync/utils"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// pairWaitTimeout bounds how long a registered host waits for a client to
+// dial in under its pairing code before the slot is released, so an
+// abandoned registration doesn't linger forever.
+const pairWaitTimeout = 5 * time.Minute
+
+// Relay lets two peers that can't reach each other directly rendezvous
+// through a process that both of them CAN reach: a host registers a
+// short pairing code, a client later dials in with that same code, and
+// the relay pipes frames between the two connections byte for byte.
+// The relay never parses what it pipes - the PAKE handshake the two sides
+// run over the tunnel themselves (see EncryptedConn) keeps it opaque even
+// to an operator running the relay.
+type Relay struct {
+	mu      sync.Mutex
+	waiting map[string]*websocket.Conn
+}
+
+func NewRelay() *Relay {
+	return &Relay{waiting: make(map[string]*websocket.Conn)}
+}
+
+// HandleHostRegister upgrades req, reads the pairing code the host wants
+// to register, and holds the connection open until a client dials in
+// under the same code via HandleClientDial or pairWaitTimeout elapses.
+func (r *Relay) HandleHostRegister(w http.ResponseWriter, req *http.Request) {
+	conn, err := Upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		utils.LogError("Relay: could not upgrade host registration: %s", err.Error())
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(pairWaitTimeout))
+	_, code, err := conn.ReadMessage()
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		utils.LogError("Relay: host did not send a pairing code: %s", err.Error())
+		conn.Close()
+		return
+	}
+
+	if !r.register(string(code), conn) {
+		conn.WriteMessage(websocket.TextMessage, []byte("code already registered"))
+		conn.Close()
+		return
+	}
+
+	utils.LogInfo("Relay: host registered under a pairing code, waiting for a client")
+
+	go func() {
+		time.Sleep(pairWaitTimeout)
+		if r.release(string(code), conn) {
+			utils.LogInfo("Relay: no client showed up in time, releasing pairing code")
+			conn.Close()
+		}
+	}()
+}
+
+// HandleClientDial upgrades req, reads the pairing code the client wants
+// to join, and - if a host is waiting under that code - pipes the two
+// connections together until either side disconnects.
+func (r *Relay) HandleClientDial(w http.ResponseWriter, req *http.Request) {
+	conn, err := Upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		utils.LogError("Relay: could not upgrade client dial: %s", err.Error())
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(handshakeWait))
+	_, code, err := conn.ReadMessage()
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		utils.LogError("Relay: client did not send a pairing code: %s", err.Error())
+		conn.Close()
+		return
+	}
+
+	hostConn, ok := r.claim(string(code))
+	if !ok {
+		conn.WriteMessage(websocket.TextMessage, []byte("no host waiting under that code"))
+		conn.Close()
+		return
+	}
+
+	utils.LogInfo("Relay: paired a client with a waiting host")
+	pipe(hostConn, conn)
+}
+
+func (r *Relay) register(code string, conn *websocket.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.waiting[code]; exists {
+		return false
+	}
+	r.waiting[code] = conn
+	return true
+}
+
+func (r *Relay) claim(code string) (*websocket.Conn, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.waiting[code]
+	if ok {
+		delete(r.waiting, code)
+	}
+	return conn, ok
+}
+
+func (r *Relay) release(code string, conn *websocket.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.waiting[code] != conn {
+		return false
+	}
+	delete(r.waiting, code)
+	return true
+}
+
+// pipe ferries binary frames between a and b until either side errors or
+// closes, then closes both.
+func pipe(a, b *websocket.Conn) {
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan struct{}, 2)
+	forward := func(from, to *websocket.Conn) {
+		defer func() { done <- struct{}{} }()
+		for {
+			msgType, data, err := from.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := to.WriteMessage(msgType, data); err != nil {
+				return
+			}
+		}
+	}
+
+	go forward(a, b)
+	go forward(b, a)
+	<-done
+}
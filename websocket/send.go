@@ -0,0 +1,20 @@
+package websocket
+
+import (
+	"JustSync/snapshot"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// SendToHost marshals msg and writes it to the active host connection. It
+// is the one send path shared by every outgoing sync message - file
+// deltas from the manual /send-sync endpoint and service.SyncFile, plus
+// the FileRemoved/FileRenamed messages service/watcher emits.
+func SendToHost(msg *snapshot.WebsocketMessage) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return GetHostConnection().WriteMessage(websocket.TextMessage, data)
+}